@@ -0,0 +1,31 @@
+package main
+
+import (
+	"AirPygee/game"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// mapcheck reports every problem of the hand made levels and of the world file
+func main() {
+	dataDir := flag.String("data", "", "directory holding the maps and defs directories to check, the embedded ones are checked by default")
+	flag.Parse()
+
+	var data fs.FS = game.DefaultData
+	if *dataDir != "" {
+		data = os.DirFS(*dataDir)
+	}
+
+	problems := game.CheckMaps(data)
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+
+	if len(problems) > 0 {
+		fmt.Printf("%d problem(s) found\n", len(problems))
+		os.Exit(1)
+	}
+	fmt.Println("no problem found")
+}
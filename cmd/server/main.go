@@ -0,0 +1,54 @@
+package main
+
+import (
+	"AirPygee/game"
+	"AirPygee/server"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// server runs a game and lets players join it over TCP, one hero of the party each
+func main() {
+	addr := flag.String("addr", ":7777", "TCP address to listen on")
+	players := flag.Int("players", 2, "number of heroes of the party, the clients joining after them only watch the game")
+	seed := flag.Int64("seed", 0, "seed used for every random decision, 0 picks a random one")
+	diagonal := flag.Bool("diagonal", false, "let the heroes and the monsters move diagonally")
+	logFile := flag.String("log", "", "append the message log of every run to this file when the run ends")
+	dataDir := flag.String("data", "", "directory holding the maps and defs directories to use instead of the embedded ones")
+	flag.Parse()
+
+	options := []game.Option{game.WithOpenSeats(), game.WithPlayers(*players)}
+	if *dataDir != "" {
+		options = append(options, game.WithData(os.DirFS(*dataDir)))
+	}
+	if *seed != 0 {
+		options = append(options, game.WithSeed(*seed))
+	}
+	if *diagonal {
+		options = append(options, game.WithDiagonalMoves())
+	}
+	if *logFile != "" {
+		options = append(options, game.WithLogFile(*logFile))
+	}
+
+	g := game.NewGame(0, options...)
+	// the game can't go on without levels, the other errors only concern what it was doing
+	go func() {
+		for err := range g.ErrorChan {
+			fmt.Println(err)
+			var startErr *game.StartError
+			if errors.As(err, &startErr) {
+				os.Exit(1)
+			}
+		}
+	}()
+	go g.Run()
+
+	fmt.Printf("serving a game of %d heroes on %s\n", *players, *addr)
+	if err := server.New(g).ListenAndServe(*addr); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
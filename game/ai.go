@@ -0,0 +1,169 @@
+package game
+
+// Behaviour is the ordered list of steps a monster tries every time it can act,
+// the first step able to act plays the action
+type Behaviour []func(game *Game, m *Monster) bool
+
+// behaviours are the monster AIs of defs/monsters.csv
+var behaviours = map[string]Behaviour{
+	"hunter": {chase, wander},
+	"coward": {flee, chase, wander},
+	"archer": {flee, keepDistance, chase, wander},
+}
+
+// act plays the first step of behaviour able to act, false if none could
+func (behaviour Behaviour) act(game *Game, m *Monster) bool {
+	for _, step := range behaviour {
+		if step(game, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// wanderChance is the percentage of turns a monster not tracking the player moves around
+const wanderChance = 30
+
+// canSee tells if pos is in the sight range of m with nothing blocking the line of sight,
+// the range is a circle as for the heroes
+func (m *Monster) canSee(level *Level, pos Pos) bool {
+	if distance(m.Pos, pos) > float64(m.SightRange) {
+		return false
+	}
+	for i, p := range bresenhamLine(m.Pos, pos) {
+		if i > 0 && !canSeeTrough(level, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// seenHero returns the closest hero m sees, nil if it sees none
+func (m *Monster) seenHero(level *Level) *Player {
+	for _, hero := range level.heroesByDistance(m.Pos) {
+		if m.canSee(level, hero.Pos) {
+			return hero
+		}
+	}
+	return nil
+}
+
+// look remembers where the closest hero is while m sees it
+func (m *Monster) look(level *Level) {
+	if hero := m.seenHero(level); hero != nil {
+		m.LastKnown = hero.Pos
+		m.Tracking = true
+	}
+}
+
+// canStep tells if m can move to pos, a free walkable tile
+func (m *Monster) canStep(level *Level, pos Pos) bool {
+	_, occupied := level.Monsters[pos]
+	return !occupied && level.heroAt(pos) == nil && canWalk(level, pos)
+}
+
+func (m *Monster) attackHero(level *Level, hero *Player) {
+	m.ActionPoints -= attackCost
+	damage := level.Attack(&m.Character, &hero.Character)
+	if m.Def.OnHit != "" && damage > 0 && hero.Health > 0 && level.rand.Intn(100) < m.Def.OnHitChance {
+		level.addEffect(&hero.Character, m.Def.OnHit)
+	}
+}
+
+// chase moves m toward the last known position of a hero, attacking it when reached,
+// m loses track of the heroes once there without seeing them.
+// Monsters seeing a hero share the flow field leading to it, the others look for their own path
+func chase(game *Game, m *Monster) bool {
+	level := game.CurrentLevel
+	if !m.Tracking {
+		return false
+	}
+	if m.Pos == m.LastKnown {
+		m.Tracking = false
+		return false
+	}
+	if level.heroAt(m.LastKnown) != nil {
+		next, ok := level.nextStep(m.Pos, m.LastKnown)
+		if !ok {
+			return false
+		}
+		m.Move(next, game)
+		return true
+	}
+	positions := level.astar(m.Pos, m.LastKnown)
+	if len(positions) < 2 {
+		m.Tracking = false
+		return false
+	}
+	m.Move(positions[1], game)
+	return true
+}
+
+// wander moves m to a random free neighbor from time to time while it doesn't track the player
+func wander(game *Game, m *Monster) bool {
+	level := game.CurrentLevel
+	if m.Tracking || level.rand.Intn(100) >= wanderChance {
+		return false
+	}
+	var free []Pos
+	for _, pos := range getNeighbors(level, m.Pos) {
+		if m.canStep(level, pos) {
+			free = append(free, pos)
+		}
+	}
+	if len(free) == 0 {
+		return false
+	}
+	m.Move(free[level.rand.Intn(len(free))], game)
+	return true
+}
+
+// flee moves m away from the heroes it sees while its health is below the flee threshold of its definition
+func flee(game *Game, m *Monster) bool {
+	level := game.CurrentLevel
+	if m.Health*100 >= m.MaxHealth*m.Def.FleeHealth || m.seenHero(level) == nil {
+		return false
+	}
+	return m.stepAway(level)
+}
+
+// keepDistance shoots the closest hero in range from afar and steps back when it comes next to m
+func keepDistance(game *Game, m *Monster) bool {
+	level := game.CurrentLevel
+	p := m.seenHero(level)
+	if m.Def.Range == 0 || p == nil {
+		return false
+	}
+	dist := level.distance(p.Pos, m.Pos)
+	if dist == 1 && m.stepAway(level) {
+		return true
+	}
+	if dist > m.Def.Range {
+		return false
+	}
+	m.attackHero(level, p)
+	return true
+}
+
+// stepAway moves m to the free neighbor the furthest from the closest hero, if it is further than where m stands
+func (m *Monster) stepAway(level *Level) bool {
+	p := level.heroesByDistance(m.Pos)[0]
+	best := m.Pos
+	bestDist := level.distance(p.Pos, m.Pos)
+	for _, pos := range getNeighbors(level, m.Pos) {
+		dist := level.distance(p.Pos, pos)
+		if dist > bestDist && m.canStep(level, pos) {
+			best = pos
+			bestDist = dist
+		}
+	}
+	if best == m.Pos {
+		return false
+	}
+	delete(level.Monsters, m.Pos)
+	level.Monsters[best] = m
+	m.Pos = best
+	m.ActionPoints -= moveCost
+	level.emit(TurnEvent{Type: Move, Actor: m.Name, ActorID: m.CharacterID(), Pos: best})
+	return true
+}
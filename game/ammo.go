@@ -0,0 +1,35 @@
+package game
+
+import "fmt"
+
+// Ammo is a stack of projectiles fired by the ranged weapons using it
+type Ammo struct {
+	Entity
+	Count int
+}
+
+func (a *Ammo) GetDescription() string {
+	return fmt.Sprintf("%s (%d left)", a.Description, a.Count)
+}
+func (a *Ammo) GetName() string {
+	return a.Name
+}
+func (a *Ammo) GetRune() rune {
+	return a.Rune
+}
+func (a *Ammo) GetEntity() *Entity {
+	return &a.Entity
+}
+func (a *Ammo) SetPos(pos Pos) {
+	a.Pos = pos
+}
+
+// ammo returns the stack of ammunition named name carried by c, nil if there is none
+func (c *Character) ammo(name string) *Ammo {
+	for _, item := range c.Items {
+		if ammo, ok := item.(*Ammo); ok && ammo.Name == name {
+			return ammo
+		}
+	}
+	return nil
+}
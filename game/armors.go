@@ -8,18 +8,6 @@ type Armor struct {
 	Location
 }
 
-type Helmet struct {
-	Armor
-}
-
-type Boots struct {
-	Armor
-}
-
-type Plate struct {
-	Armor
-}
-
 func (a *Armor) GetDescription() string {
 	return a.Description
 }
@@ -68,69 +56,3 @@ func (a *Armor) ToString(rarity Rarity) string {
 func (a *Armor) GetLocation() Location {
 	return a.Location
 }
-
-func NewPlate(p Pos) *Boots {
-	rarity := randomizeRarity()
-	stats := adaptStatsToRarity(rarity, &EquipableItemStats{
-		MinDamage: 0,
-		MaxDamage: 0,
-		Armor:     10,
-		Critical:  0,
-	})
-	return &Boots{Armor: Armor{
-		Entity: Entity{
-			Pos:         p,
-			Name:        "Plate",
-			Rune:        'a',
-			Type:        Armors,
-			Description: "Common plate...",
-		},
-		Location:           Chest,
-		Rarity:             rarity,
-		EquipableItemStats: *stats,
-	}}
-}
-
-func NewBoots(p Pos) *Boots {
-	rarity := randomizeRarity()
-	stats := adaptStatsToRarity(rarity, &EquipableItemStats{
-		MinDamage: 0,
-		MaxDamage: 0,
-		Armor:     5,
-		Critical:  0,
-	})
-	return &Boots{Armor: Armor{
-		Entity: Entity{
-			Pos:         p,
-			Name:        "Boots",
-			Rune:        'b',
-			Type:        Armors,
-			Description: "Common boots...",
-		},
-		Location:           Foots,
-		Rarity:             rarity,
-		EquipableItemStats: *stats,
-	}}
-}
-
-func NewHelmet(p Pos) *Helmet {
-	rarity := randomizeRarity()
-	stats := adaptStatsToRarity(rarity, &EquipableItemStats{
-		MinDamage: 0,
-		MaxDamage: 0,
-		Armor:     5,
-		Critical:  0,
-	})
-	return &Helmet{Armor: Armor{
-		Entity: Entity{
-			Pos:         p,
-			Name:        "Helmet",
-			Rune:        'h',
-			Type:        Armors,
-			Description: "A common helmet...",
-		},
-		Location:           Head,
-		Rarity:             rarity,
-		EquipableItemStats: *stats,
-	}}
-}
@@ -1,5 +1,7 @@
 package game
 
+import "math/rand"
+
 type TreasureChest struct {
 	Entity
 	Size   int
@@ -44,8 +46,16 @@ func (t *TreasureChest) Close() {
 	t.Opened = false
 }
 
-func NewTreasureChest(p Pos, size int) *TreasureChest {
-	items := randomLoot(p, size+1)
+// newChest creates a chest of size at p filled from its loot table
+func (game *Game) newChest(p Pos, size int) *TreasureChest {
+	chest := NewTreasureChest(game.rand, game.defs.LootTables[chestLoot(size)], p, size)
+	chest.Items = game.lootFor(chest.Items)
+	return chest
+}
+
+// NewTreasureChest creates a chest at p filled from loot
+func NewTreasureChest(r *rand.Rand, loot *LootTable, p Pos, size int) *TreasureChest {
+	items := loot.Roll(r, p)
 	return &TreasureChest{
 		Entity: Entity{
 			Pos:         p,
@@ -77,10 +87,11 @@ func (game *Game) OpenItem(chest OpenableItem) {
 	game.CurrentLevel.Items[game.CurrentLevel.Player.Pos] = chest.GetItems()
 	chest.RemoveItems()
 	chest.Open()
-	game.CurrentLevel.AddEvent(game.CurrentLevel.Player.Name + " Opened chest")
-	game.CurrentLevel.LastEvent = OpenChest
+	game.CurrentLevel.AddMessage(Loot, Info, game.CurrentLevel.Player.Name+" Opened chest")
+	game.CurrentLevel.emit(TurnEvent{Type: OpenChest, Actor: game.CurrentLevel.Player.Name, ActorID: game.CurrentLevel.Player.CharacterID(), Pos: chest.GetPos()})
 	game.CurrentLevel.Map[chest.GetPos().Y][chest.GetPos().X].Actionable = false
 	game.CurrentLevel.Map[chest.GetPos().Y][chest.GetPos().X].Walkable = true
+	game.CurrentLevel.invalidatePaths()
 	game.removeChest(chest.GetPos())
 	game.CurrentLevel.lineOfSight()
 }
@@ -0,0 +1,170 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// ClassDef is a hero class read from defs/classes.csv
+type ClassDef struct {
+	Name          string
+	Health        int
+	Mana          int
+	MinDamage     int
+	MaxDamage     int
+	Armor         int
+	Critical      float64
+	Speed         float64
+	SightRange    int
+	InventorySize int
+	// Slots are the equipment locations the class can use
+	Slots []Location
+	// Equipment is equipped on the player when a game starts, ammunition goes to the inventory
+	Equipment []*ItemDef
+	Ability   string
+	// Spells is the spellbook of the class, cast with the keys 1 to 4
+	Spells []*Spell
+	// Sprite is the sprite sheet of the class in the ui assets, holding 4x2 characters,
+	// SpriteCol and SpriteRow tell which one is the class
+	Sprite               string
+	SpriteCol, SpriteRow int
+	Description          string
+}
+
+// Ability is an action a class can use again once its cooldown, in turns, is over
+type Ability struct {
+	Name        string
+	Description string
+	Cooldown    int
+	// use returns false when the ability had no effect, the cooldown is then not started
+	use func(game *Game) bool
+}
+
+var abilities = map[string]*Ability{
+	"whirlwind": {
+		Name:        "Whirlwind",
+		Description: "Attacks every monster around",
+		Cooldown:    8,
+		use:         whirlwind,
+	},
+	"eagle_eye": {
+		Name:        "Eagle eye",
+		Description: "Reveals the map up to twice the sight range",
+		Cooldown:    30,
+		use:         eagleEye,
+	},
+	"heal": {
+		Name:        "Heal",
+		Description: "Restores half of the health",
+		Cooldown:    40,
+		use:         healSpell,
+	},
+}
+
+// NewPlayer creates a player of class with its starting equipment equipped
+func NewPlayer(r *rand.Rand, class *ClassDef) *Player {
+	player := &Player{Class: class, Satiation: maxSatiation, Character: Character{
+		Entity:        Entity{Name: class.Name, Rune: '@'},
+		Health:        class.Health,
+		MaxHealth:     class.Health,
+		Mana:          class.Mana,
+		MaxMana:       class.Mana,
+		MinDamage:     class.MinDamage,
+		MaxDamage:     class.MaxDamage,
+		Armor:         class.Armor,
+		Critical:      class.Critical,
+		Speed:         class.Speed,
+		ActionPoints:  actionThreshold,
+		SightRange:    class.SightRange,
+		InventorySize: class.InventorySize,
+	}}
+
+	for _, def := range class.Equipment {
+		if def.Type == Ammunition {
+			player.Items = append(player.Items, NewItem(r, def, Pos{}))
+			continue
+		}
+		item := NewItem(r, def, Pos{}).(EquipableItem)
+		item.Equip()
+		player.addStats(item.GetStats(), 1)
+		player.EquippedItems = append(player.EquippedItems, item)
+	}
+	return player
+}
+
+// CanEquip tells if the class of the player can use the location of item
+func (p *Player) CanEquip(item EquipableItem) bool {
+	return containsLocation(p.Class.Slots, item.GetLocation())
+}
+
+func (p *Player) addStats(stats *EquipableItemStats, sign int) {
+	p.MinDamage += sign * stats.MinDamage
+	p.MaxDamage += sign * stats.MaxDamage
+	p.Critical += float64(sign) * stats.Critical
+	p.Armor += sign * stats.Armor
+}
+
+func (game *Game) useAbility() {
+	level := game.CurrentLevel
+	p := level.Player
+	ability := abilities[p.Class.Ability]
+	if p.AbilityCooldown > 0 {
+		level.AddMessage(Status, Info, fmt.Sprintf("%s is ready in %d turns", ability.Name, p.AbilityCooldown))
+		return
+	}
+	if ability.use(game) {
+		p.AbilityCooldown = ability.Cooldown
+		p.ActionPoints -= abilityCost
+	}
+}
+
+func whirlwind(game *Game) bool {
+	level := game.CurrentLevel
+	p := level.Player
+	hit := false
+	for y := p.Y - 1; y <= p.Y+1; y++ {
+		for x := p.X - 1; x <= p.X+1; x++ {
+			monster, exists := level.Monsters[Pos{x, y}]
+			if !exists {
+				continue
+			}
+			level.Attack(&p.Character, &monster.Character)
+			if monster.Health <= 0 {
+				game.killMonster(monster)
+			}
+			hit = true
+		}
+	}
+	if !hit {
+		level.AddMessage(Combat, Info, "No monster around")
+	}
+	return hit
+}
+
+func eagleEye(game *Game) bool {
+	level := game.CurrentLevel
+	p := level.Player
+	dist := p.SightRange * 2
+	view := level.view(p.ID)
+	for y := p.Y - dist; y <= p.Y+dist; y++ {
+		for x := p.X - dist; x <= p.X+dist; x++ {
+			if inRange(level, Pos{x, y}) {
+				level.Map[y][x].Seen = true
+				view.Seen[y][x] = true
+			}
+		}
+	}
+	level.AddEvent(p.Name + " looks around")
+	return true
+}
+
+func healSpell(game *Game) bool {
+	p := game.CurrentLevel.Player
+	if p.Health == p.MaxHealth {
+		game.CurrentLevel.AddMessage(Status, Info, p.Name+" is not hurt")
+		return false
+	}
+	game.heal(&p.Character, p.MaxHealth/2)
+	game.CurrentLevel.AddMessage(Status, Good, p.Name+" healed")
+	return true
+}
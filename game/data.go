@@ -0,0 +1,15 @@
+package game
+
+import "embed"
+
+//go:embed maps defs
+var embeddedData embed.FS
+
+// DefaultData holds the levels and the world file under maps/ and the definitions under defs/, baked into the binary
+var DefaultData = embeddedData
+
+// mapDir is where the levels and the world file are found in the game data
+const mapDir = "maps"
+
+// defsDir is where the monster definitions are found in the game data
+const defsDir = "defs"
@@ -0,0 +1,592 @@
+package game
+
+import (
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// reservedGlyphs are the map characters that can't be used by a monster or an item placement
+const reservedGlyphs = " \t#.|/dut@"
+
+// MonsterDef is a monster archetype read from defs/monsters.csv
+type MonsterDef struct {
+	Name       string
+	Glyph      rune
+	Health     int
+	MinDamage  int
+	MaxDamage  int
+	Critical   float64
+	Armor      int
+	Speed      float64
+	SightRange int
+	Loot       string
+	// XP is the experience the player gets for killing the monster
+	XP int
+	// OnHit is the effect the monster applies with OnHitChance percent of its successful attacks, none when empty
+	OnHit       string
+	OnHitChance int
+	// AI is the behaviour of the monster, FleeHealth the health percentage it flees under
+	// and Range how far it attacks from, 0 for melee monsters
+	AI         string
+	FleeHealth int
+	Range      int
+	// Weights is the spawn weight for the easy, medium and hard difficulties
+	Weights [3]int
+	// MinDepth and MaxDepth bound the depths the monster spawns at, MaxDepth 0 means no limit
+	MinDepth, MaxDepth int
+	// DepthWeight is added to the spawn weight for every depth below MinDepth
+	DepthWeight int
+	// AtlasX, AtlasY and AtlasVariations locate the monster sprites in the tiles atlas
+	AtlasX, AtlasY, AtlasVariations int
+
+	loot *LootTable
+}
+
+// Defs gathers every definition the game is built from
+type Defs struct {
+	Monsters   []*MonsterDef
+	Items      map[string]*ItemDef
+	LootTables map[string]*LootTable
+	// Placements tells which loot table an item character of the maps draws from
+	Placements map[rune]*LootTable
+	// Classes are in the order of the file, the first one is picked when none is selected
+	Classes []*ClassDef
+	// Levels are the steps of the player progression, Levels[0] is level 1
+	Levels          []*LevelDef
+	monstersByGlyph map[rune]*MonsterDef
+	monstersByName  map[string]*MonsterDef
+}
+
+var itemColumns = []string{"name", "kind", "glyph", "location", "min_damage", "max_damage", "armor", "critical", "range", "ammo", "count",
+	"size", "effect", "nutrition", "description"}
+
+var lootColumns = []string{"table", "drop", "weight", "count"}
+
+var placementColumns = []string{"glyph", "loot"}
+
+var classColumns = []string{"name", "health", "mana", "min_damage", "max_damage", "armor", "critical", "speed", "sight", "inventory",
+	"slots", "equipment", "ability", "spells", "sprite", "sprite_col", "sprite_row", "description"}
+
+var levelColumns = []string{"level", "xp", "health", "mana", "min_damage", "max_damage", "critical", "sight"}
+
+var monsterColumns = []string{"name", "glyph", "health", "min_damage", "max_damage", "critical", "armor", "speed", "sight", "loot",
+	"xp", "on_hit", "on_hit_chance", "ai", "flee_health", "range", "weight_easy", "weight_medium", "weight_hard", "min_depth", "max_depth", "depth_weight", "atlas_x", "atlas_y", "atlas_variations"}
+
+// LoadDefs reads the definitions from the defs directory of data
+func LoadDefs(data fs.FS) (*Defs, error) {
+	defs := &Defs{
+		Items:           make(map[string]*ItemDef),
+		LootTables:      make(map[string]*LootTable),
+		Placements:      make(map[rune]*LootTable),
+		monstersByGlyph: make(map[rune]*MonsterDef),
+		monstersByName:  make(map[string]*MonsterDef),
+	}
+
+	// every file depends on the previous ones, so the first one in error stops the loading
+	for _, load := range []func(fs.FS) error{defs.loadItems, defs.loadLootTables, defs.loadPlacements, defs.loadMonsters, defs.loadClasses, defs.loadLevels} {
+		if err := load(data); err != nil {
+			return nil, err
+		}
+	}
+	return defs, nil
+}
+
+func (defs *Defs) loadItems(data fs.FS) error {
+	t, err := readTable(data, path.Join(defsDir, "items.csv"), itemColumns...)
+	if err != nil {
+		return err
+	}
+
+	errs := t.each(func(row *tableRow) {
+		def := &ItemDef{
+			Name:  row.str("name"),
+			Glyph: row.glyph("glyph"),
+			Stats: EquipableItemStats{
+				MinDamage: row.int("min_damage"),
+				MaxDamage: row.int("max_damage"),
+				Armor:     row.int("armor"),
+				Critical:  row.float("critical"),
+			},
+			Range:       row.int("range"),
+			Ammo:        row.str("ammo"),
+			Count:       row.int("count"),
+			Size:        row.str("size"),
+			Effect:      row.str("effect"),
+			Nutrition:   row.int("nutrition"),
+			Description: row.str("description"),
+		}
+
+		switch {
+		case def.Name == "":
+			row.fail("name", "must not be empty", nil)
+		case defs.Items[def.Name] != nil:
+			row.fail("name", "already defined", nil)
+		}
+		if def.Stats.MinDamage > def.Stats.MaxDamage {
+			row.fail("min_damage", "greater than max_damage", nil)
+		}
+
+		kind := row.str("kind")
+		switch kind {
+		case "weapon":
+			def.Type = Weapons
+		case "armor":
+			def.Type = Armors
+		case "potion":
+			def.Type = Potions
+		case "ammo":
+			def.Type = Ammunition
+		case "food":
+			def.Type = Foods
+		default:
+			row.fail("kind", fmt.Sprintf("unknown kind %q, expected weapon, armor, potion, ammo or food", kind), nil)
+		}
+
+		location := row.str("location")
+		if def.Type == Weapons || def.Type == Armors {
+			var exists bool
+			def.Location, exists = locations[location]
+			if !exists {
+				row.fail("location", fmt.Sprintf("unknown location %q", location), nil)
+			}
+		}
+		if def.Type != Weapons && (def.Range != 0 || def.Ammo != "") {
+			row.fail("range", "only weapons can have a range and an ammo", nil)
+		}
+		if def.Ammo != "" && def.Range == 0 {
+			row.fail("ammo", "melee weapons can't use ammo", nil)
+		}
+		if def.Effect != "" && (def.Type != Potions || effects[def.Effect] == nil) {
+			row.fail("effect", fmt.Sprintf("unknown potion effect %q", def.Effect), nil)
+		}
+		if def.Type == Ammunition && def.Count == 0 {
+			row.fail("count", "must be positive", nil)
+		}
+		if (def.Type == Foods) != (def.Nutrition > 0) {
+			row.fail("nutrition", "must be positive for food only", nil)
+		}
+		if def.Type == Potions && def.Size != "Small" && def.Size != "Medium" && def.Size != "Large" {
+			row.fail("size", fmt.Sprintf("unknown potion size %q, expected Small, Medium or Large", def.Size), nil)
+		}
+
+		defs.Items[def.Name] = def
+	})
+
+	// ammunition can be defined after the weapons using it
+	errs = append(errs, t.each(func(row *tableRow) {
+		ammo := row.str("ammo")
+		if ammo == "" {
+			return
+		}
+		if def := defs.Items[ammo]; def == nil || def.Type != Ammunition {
+			row.fail("ammo", fmt.Sprintf("unknown ammunition %q", ammo), nil)
+		}
+	})...)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (defs *Defs) loadLootTables(data fs.FS) error {
+	t, err := readTable(data, path.Join(defsDir, "loot.csv"), lootColumns...)
+	if err != nil {
+		return err
+	}
+
+	// tables are created first so an entry can refer to a table defined further in the file
+	for _, row := range t.rows {
+		name := row.str("table")
+		if defs.LootTables[name] == nil {
+			defs.LootTables[name] = &LootTable{Name: name}
+		}
+	}
+
+	errs := t.each(func(row *tableRow) {
+		table := defs.LootTables[row.str("table")]
+		entry := lootEntry{weight: row.int("weight"), count: row.int("count")}
+
+		drop := row.str("drop")
+		item, table2 := defs.Items[drop], defs.LootTables[drop]
+		switch {
+		case table.Name == "":
+			row.fail("table", "must not be empty", nil)
+		case drop == "" || drop == "nothing":
+		case item != nil && table2 != nil:
+			row.fail("drop", fmt.Sprintf("%q is both an item and a loot table", drop), nil)
+		case item != nil:
+			entry.item = item
+		case table2 != nil:
+			entry.table = table2
+		default:
+			row.fail("drop", fmt.Sprintf("unknown item or loot table %q", drop), nil)
+		}
+
+		table.entries = append(table.entries, entry)
+		table.total += entry.weight
+	})
+
+	for _, name := range defs.sortedLootTables() {
+		table := defs.LootTables[name]
+		if name == "" {
+			continue
+		}
+		if table.total == 0 {
+			errs = append(errs, &DataError{File: t.file, Line: 1, Column: "weight", Reason: fmt.Sprintf("loot table %q can never drop anything", name)})
+		}
+		if table.loops(nil) {
+			errs = append(errs, &DataError{File: t.file, Line: 1, Column: "drop", Reason: fmt.Sprintf("loot table %q draws from itself, directly or through other tables", name)})
+		}
+	}
+	for size := 1; size <= maxChestSize; size++ {
+		if defs.LootTables[chestLoot(size)] == nil {
+			errs = append(errs, &DataError{File: t.file, Line: 1, Column: "table", Reason: fmt.Sprintf("missing loot table %q", chestLoot(size))})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (defs *Defs) loadPlacements(data fs.FS) error {
+	t, err := readTable(data, path.Join(defsDir, "placements.csv"), placementColumns...)
+	if err != nil {
+		return err
+	}
+
+	errs := t.each(func(row *tableRow) {
+		glyph := row.glyph("glyph")
+		switch {
+		case strings.ContainsRune(reservedGlyphs, glyph):
+			row.fail("glyph", fmt.Sprintf("%q is already used by the maps", glyph), nil)
+		case defs.Placements[glyph] != nil:
+			row.fail("glyph", fmt.Sprintf("%q is already placed", glyph), nil)
+		}
+
+		loot := defs.LootTables[row.str("loot")]
+		if loot == nil {
+			row.fail("loot", fmt.Sprintf("unknown loot table %q", row.str("loot")), nil)
+		}
+		defs.Placements[glyph] = loot
+	})
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (defs *Defs) loadMonsters(data fs.FS) error {
+	t, err := readTable(data, path.Join(defsDir, "monsters.csv"), monsterColumns...)
+	if err != nil {
+		return err
+	}
+
+	errs := t.each(func(row *tableRow) {
+		def := &MonsterDef{
+			Name:            row.str("name"),
+			Glyph:           row.glyph("glyph"),
+			Health:          row.int("health"),
+			MinDamage:       row.int("min_damage"),
+			MaxDamage:       row.int("max_damage"),
+			Critical:        row.float("critical"),
+			Armor:           row.int("armor"),
+			Speed:           row.float("speed"),
+			SightRange:      row.int("sight"),
+			Loot:            row.str("loot"),
+			XP:              row.int("xp"),
+			OnHit:           row.str("on_hit"),
+			OnHitChance:     row.int("on_hit_chance"),
+			AI:              row.str("ai"),
+			FleeHealth:      row.int("flee_health"),
+			Range:           row.int("range"),
+			Weights:         [3]int{row.int("weight_easy"), row.int("weight_medium"), row.int("weight_hard")},
+			MinDepth:        row.int("min_depth"),
+			MaxDepth:        row.int("max_depth"),
+			DepthWeight:     row.int("depth_weight"),
+			AtlasX:          row.int("atlas_x"),
+			AtlasY:          row.int("atlas_y"),
+			AtlasVariations: row.int("atlas_variations"),
+		}
+
+		switch {
+		case strings.ContainsRune(reservedGlyphs, def.Glyph):
+			row.fail("glyph", fmt.Sprintf("%q is already used by the maps", def.Glyph), nil)
+		case defs.Placements[def.Glyph] != nil:
+			row.fail("glyph", fmt.Sprintf("%q is already used by an item placement", def.Glyph), nil)
+		case defs.monstersByGlyph[def.Glyph] != nil:
+			row.fail("glyph", fmt.Sprintf("%q is already used by %s", def.Glyph, defs.monstersByGlyph[def.Glyph].Name), nil)
+		}
+
+		switch {
+		case def.Name == "":
+			row.fail("name", "must not be empty", nil)
+		case defs.monstersByName[def.Name] != nil:
+			row.fail("name", "already defined", nil)
+		}
+		if def.MinDamage > def.MaxDamage {
+			row.fail("min_damage", "greater than max_damage", nil)
+		}
+		if def.Health == 0 {
+			row.fail("health", "must be positive", nil)
+		}
+		if def.Speed <= 0 {
+			row.fail("speed", "must be positive", nil)
+		}
+		if def.MaxDepth != 0 && def.MaxDepth < def.MinDepth {
+			row.fail("max_depth", "lower than min_depth", nil)
+		}
+		if def.OnHit != "" && effects[def.OnHit] == nil {
+			row.fail("on_hit", fmt.Sprintf("unknown effect %q", def.OnHit), nil)
+		}
+		if def.OnHitChance > 100 {
+			row.fail("on_hit_chance", "must be a percentage", nil)
+		}
+		if behaviours[def.AI] == nil {
+			row.fail("ai", fmt.Sprintf("unknown behaviour %q", def.AI), nil)
+		}
+		if def.FleeHealth > 100 {
+			row.fail("flee_health", "must be a percentage", nil)
+		}
+		def.loot = defs.LootTables[def.Loot]
+		if def.loot == nil {
+			row.fail("loot", fmt.Sprintf("unknown loot table %q", def.Loot), nil)
+		}
+		if def.AtlasVariations == 0 {
+			row.fail("atlas_variations", "must be positive", nil)
+		}
+
+		defs.Monsters = append(defs.Monsters, def)
+		defs.monstersByGlyph[def.Glyph] = def
+		defs.monstersByName[def.Name] = def
+	})
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (defs *Defs) loadClasses(data fs.FS) error {
+	t, err := readTable(data, path.Join(defsDir, "classes.csv"), classColumns...)
+	if err != nil {
+		return err
+	}
+
+	errs := t.each(func(row *tableRow) {
+		class := &ClassDef{
+			Name:          row.str("name"),
+			Health:        row.int("health"),
+			Mana:          row.int("mana"),
+			MinDamage:     row.int("min_damage"),
+			MaxDamage:     row.int("max_damage"),
+			Armor:         row.int("armor"),
+			Critical:      row.float("critical"),
+			Speed:         row.float("speed"),
+			SightRange:    row.int("sight"),
+			InventorySize: row.int("inventory"),
+			Ability:       row.str("ability"),
+			Sprite:        row.str("sprite"),
+			SpriteCol:     row.int("sprite_col"),
+			SpriteRow:     row.int("sprite_row"),
+			Description:   row.str("description"),
+		}
+
+		switch {
+		case class.Name == "":
+			row.fail("name", "must not be empty", nil)
+		case defs.Class(class.Name) != nil:
+			row.fail("name", "already defined", nil)
+		}
+		if class.Health == 0 {
+			row.fail("health", "must be positive", nil)
+		}
+		if class.Speed <= 0 {
+			row.fail("speed", "must be positive", nil)
+		}
+		if class.MinDamage > class.MaxDamage {
+			row.fail("min_damage", "greater than max_damage", nil)
+		}
+		if abilities[class.Ability] == nil {
+			row.fail("ability", fmt.Sprintf("unknown ability %q", class.Ability), nil)
+		}
+		if class.Sprite == "" {
+			row.fail("sprite", "must not be empty", nil)
+		}
+		if class.SpriteCol > 3 || class.SpriteRow > 1 {
+			row.fail("sprite_col", "sprite sheets hold 4x2 characters", nil)
+		}
+
+		for _, name := range strings.Fields(row.str("spells")) {
+			spell, exists := spells[name]
+			if !exists {
+				row.fail("spells", fmt.Sprintf("unknown spell %q", name), nil)
+				continue
+			}
+			if spell.Mana > class.Mana {
+				row.fail("spells", fmt.Sprintf("%s costs more than the class mana", name), nil)
+			}
+			class.Spells = append(class.Spells, spell)
+		}
+		if len(class.Spells) > maxSpells {
+			row.fail("spells", fmt.Sprintf("at most %d spells are allowed", maxSpells), nil)
+		}
+
+		for _, name := range strings.Fields(row.str("slots")) {
+			location, exists := locations[name]
+			if !exists {
+				row.fail("slots", fmt.Sprintf("unknown location %q", name), nil)
+			}
+			class.Slots = append(class.Slots, location)
+		}
+
+		used := make(map[Location]bool)
+		for _, name := range strings.Fields(row.str("equipment")) {
+			item := defs.Items[name]
+			switch {
+			case item == nil:
+				row.fail("equipment", fmt.Sprintf("unknown item %q", name), nil)
+				continue
+			case item.Type == Ammunition:
+				class.Equipment = append(class.Equipment, item)
+				continue
+			case item.Type != Weapons && item.Type != Armors:
+				row.fail("equipment", fmt.Sprintf("%s can't be equipped", name), nil)
+				continue
+			case used[item.Location]:
+				row.fail("equipment", fmt.Sprintf("%s uses an already equipped slot", name), nil)
+			}
+			used[item.Location] = true
+			class.Equipment = append(class.Equipment, item)
+		}
+		for location := range used {
+			if !containsLocation(class.Slots, location) {
+				row.fail("equipment", "starting equipment uses a slot the class can't use", nil)
+			}
+		}
+
+		defs.Classes = append(defs.Classes, class)
+	})
+	if len(t.rows) == 0 {
+		errs = append(errs, &DataError{File: t.file, Line: 1, Reason: "at least one class is needed"})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (defs *Defs) loadLevels(data fs.FS) error {
+	t, err := readTable(data, path.Join(defsDir, "levels.csv"), levelColumns...)
+	if err != nil {
+		return err
+	}
+
+	errs := t.each(func(row *tableRow) {
+		def := &LevelDef{
+			Level:      row.int("level"),
+			XP:         row.int("xp"),
+			Health:     row.int("health"),
+			Mana:       row.int("mana"),
+			MinDamage:  row.int("min_damage"),
+			MaxDamage:  row.int("max_damage"),
+			Critical:   row.float("critical"),
+			SightRange: row.int("sight"),
+		}
+
+		if def.Level != len(defs.Levels)+1 {
+			row.fail("level", fmt.Sprintf("expected level %d, levels must follow each other from 1", len(defs.Levels)+1), nil)
+		}
+		switch {
+		case def.Level == 1 && def.XP != 0:
+			row.fail("xp", "level 1 must need no experience", nil)
+		case len(defs.Levels) > 0 && def.XP <= defs.Levels[len(defs.Levels)-1].XP:
+			row.fail("xp", "must be greater than the previous level", nil)
+		}
+
+		defs.Levels = append(defs.Levels, def)
+	})
+	if len(t.rows) == 0 {
+		errs = append(errs, &DataError{File: t.file, Line: 1, Reason: "at least level 1 is needed"})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Class returns the class named name, nil if there is none
+func (defs *Defs) Class(name string) *ClassDef {
+	for _, class := range defs.Classes {
+		if class.Name == name {
+			return class
+		}
+	}
+	return nil
+}
+
+func containsLocation(locations []Location, location Location) bool {
+	for _, l := range locations {
+		if l == location {
+			return true
+		}
+	}
+	return false
+}
+
+func (defs *Defs) sortedLootTables() []string {
+	names := make([]string, 0, len(defs.LootTables))
+	for name := range defs.LootTables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (row *tableRow) glyph(column string) rune {
+	value := row.str(column)
+	glyph, _ := utf8.DecodeRuneInString(value)
+	if utf8.RuneCountInString(value) != 1 {
+		row.fail(column, "must be a single character", nil)
+	}
+	return glyph
+}
+
+// spawnWeight tells how likely the monster appears at depth, 0 if it can't
+func (def *MonsterDef) spawnWeight(difficulty, depth int) int {
+	if depth < def.MinDepth || (def.MaxDepth != 0 && depth > def.MaxDepth) {
+		return 0
+	}
+	if difficulty < 1 {
+		difficulty = 1
+	}
+	if difficulty > len(def.Weights) {
+		difficulty = len(def.Weights)
+	}
+	return def.Weights[difficulty-1] + def.DepthWeight*(depth-def.MinDepth)
+}
+
+// randomMonsterDef picks a monster by spawn weight, nil if none can appear at depth
+func (defs *Defs) randomMonsterDef(r *rand.Rand, difficulty, depth int) *MonsterDef {
+	total := 0
+	for _, def := range defs.Monsters {
+		total += def.spawnWeight(difficulty, depth)
+	}
+	if total == 0 {
+		return nil
+	}
+
+	n := r.Intn(total)
+	for _, def := range defs.Monsters {
+		n -= def.spawnWeight(difficulty, depth)
+		if n < 0 {
+			return def
+		}
+	}
+	return nil
+}
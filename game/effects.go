@@ -0,0 +1,162 @@
+package game
+
+import "fmt"
+
+// Stacking tells what happens when an effect is applied to a character already suffering from it
+type Stacking int
+
+const (
+	// Refresh restarts the effect duration
+	Refresh Stacking = iota
+	// Intensify adds the power of both effects and keeps the longest duration
+	Intensify
+	// Ignore keeps the effect running unchanged
+	Ignore
+)
+
+// EffectDef is a kind of status effect, lasting Turns turns with Power given to its tick
+type EffectDef struct {
+	Name     string
+	Turns    int
+	Power    int
+	Stacking Stacking
+	// tick runs once per turn while the effect lasts, nil for effects only checked by the game rules
+	tick func(level *Level, c *Character, effect *Effect)
+}
+
+// Effect is a status effect on a character
+type Effect struct {
+	Name  string `json:"name"`
+	Turns int    `json:"turns"`
+	Power int    `json:"power"`
+}
+
+var effects = map[string]*EffectDef{
+	"poison": {
+		Name:     "Poison",
+		Turns:    5,
+		Power:    1,
+		Stacking: Intensify,
+		tick:     damageTick("poison"),
+	},
+	"bleed": {
+		Name:     "Bleed",
+		Turns:    3,
+		Power:    2,
+		Stacking: Refresh,
+		tick:     damageTick("bleeds"),
+	},
+	"stun": {
+		Name:     "Stun",
+		Turns:    1,
+		Stacking: Ignore,
+	},
+	// haste and slow change the energy the character gains each tick
+	"haste": {
+		Name:     "Haste",
+		Turns:    10,
+		Stacking: Refresh,
+	},
+	"slow": {
+		Name:     "Slow",
+		Turns:    5,
+		Stacking: Refresh,
+	},
+	"regeneration": {
+		Name:     "Regeneration",
+		Turns:    10,
+		Power:    2,
+		Stacking: Refresh,
+		tick: func(level *Level, c *Character, effect *Effect) {
+			c.Health += effect.Power
+			if c.Health > c.MaxHealth {
+				c.Health = c.MaxHealth
+			}
+		},
+	},
+}
+
+func damageTick(verb string) func(level *Level, c *Character, effect *Effect) {
+	return func(level *Level, c *Character, effect *Effect) {
+		c.Health -= effect.Power
+		level.addMessageAt(c.Pos, Combat, level.harmSeverity(c), fmt.Sprintf("%s suffers %d from %s", c.Name, effect.Power, verb))
+	}
+}
+
+// addEffect applies the effect named name to c following the stacking rule of the effect
+func (level *Level) addEffect(c *Character, name string) {
+	def := effects[name]
+	if current := c.Effect(name); current != nil {
+		switch def.Stacking {
+		case Refresh:
+			current.Turns = def.Turns
+		case Intensify:
+			current.Power += def.Power
+			if current.Turns < def.Turns {
+				current.Turns = def.Turns
+			}
+		}
+		return
+	}
+	c.Effects = append(c.Effects, &Effect{Name: name, Turns: def.Turns, Power: def.Power})
+	level.addMessageAt(c.Pos, Status, level.harmSeverity(c), fmt.Sprintf("%s is affected by %s", c.Name, def.Name))
+}
+
+// Effect returns the effect named name on c, nil if c is not affected by it
+func (c *Character) Effect(name string) *Effect {
+	for _, effect := range c.Effects {
+		if effect.Name == name {
+			return effect
+		}
+	}
+	return nil
+}
+
+// tickEffects runs the effects of c for one turn and removes the ones that are over
+func (level *Level) tickEffects(c *Character) {
+	remaining := c.Effects[:0]
+	for _, effect := range c.Effects {
+		if tick := effects[effect.Name].tick; tick != nil {
+			tick(level, c, effect)
+		}
+		effect.Turns--
+		if effect.Turns > 0 {
+			remaining = append(remaining, effect)
+		}
+	}
+	c.Effects = remaining
+}
+
+// updateEffects ticks the effects of the heroes and of the monsters of the current level,
+// monsters dying from them are killed by the player
+func (game *Game) updateEffects() {
+	level := game.CurrentLevel
+	for _, hero := range level.Players {
+		level.tickEffects(&hero.Character)
+	}
+	for _, monster := range level.sortedMonsters() {
+		level.tickEffects(&monster.Character)
+		if monster.Health <= 0 {
+			level.addMessageAt(monster.Pos, Combat, Good, monster.Name+" died")
+			game.killMonster(monster)
+		}
+	}
+}
+
+// takesTurn tells if the input is an action of the player, which a stunned player can't do
+func (typ InputType) takesTurn() bool {
+	switch typ {
+	case Up, Down, Left, Right, UpLeft, UpRight, DownLeft, DownRight, Action, UseAbility, CastSpell, Fire:
+		return true
+	}
+	return false
+}
+
+// isHeroAction tells if the input is played by a hero, which needs the energy to act
+func (typ InputType) isHeroAction() bool {
+	switch typ {
+	case TakeAll, TakeItem, Equip, Drop:
+		return true
+	}
+	return typ.takesTurn()
+}
@@ -0,0 +1,98 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MapError is an invalid character found in a level file
+type MapError struct {
+	File   string
+	Line   int
+	Column int
+	Rune   rune
+}
+
+func (e *MapError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: invalid character %q in map", e.File, e.Line, e.Column, e.Rune)
+}
+
+// WorldError is an invalid entry of the world file, like an unknown level name or bad portal coordinates
+type WorldError struct {
+	File   string
+	Line   int
+	Level  string
+	Reason string
+	Err    error
+}
+
+func (e *WorldError) Error() string {
+	msg := fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Reason)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *WorldError) Unwrap() error {
+	return e.Err
+}
+
+// LevelError is a problem found at a position of a level, like an unreachable region
+type LevelError struct {
+	File   string
+	Pos    Pos
+	Reason string
+}
+
+func (e *LevelError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Pos.Y+1, e.Pos.X+1, e.Reason)
+}
+
+// LoadErrors gathers every problem found while loading the levels and the world
+type LoadErrors []error
+
+func (e LoadErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// DataError is an invalid entry of a definition file, like a missing column or a stat that isn't a number
+type DataError struct {
+	File   string
+	Line   int
+	Column string
+	Reason string
+	Err    error
+}
+
+func (e *DataError) Error() string {
+	msg := fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Reason)
+	if e.Column != "" {
+		msg = fmt.Sprintf("%s:%d: %s: %s", e.File, e.Line, e.Column, e.Reason)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *DataError) Unwrap() error {
+	return e.Err
+}
+
+// StartError is a run that could not start, like levels that failed to load, the game has no level to play then
+type StartError struct {
+	Err error
+}
+
+func (e *StartError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StartError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,70 @@
+package game
+
+import (
+	"fmt"
+)
+
+// CharacterID identifies a character for the whole run, like "hero:0" for the first hero of the party or "monster:12"
+type CharacterID string
+
+// CharacterID returns the identifier of the hero p
+func (p *Player) CharacterID() CharacterID {
+	return CharacterID(fmt.Sprintf("hero:%d", p.ID))
+}
+
+// CharacterID returns the identifier of the monster m
+func (m *Monster) CharacterID() CharacterID {
+	return CharacterID(fmt.Sprintf("monster:%d", m.ID))
+}
+
+// characterID returns the identifier of c, empty when c is neither a hero nor a monster of level
+func (level *Level) characterID(c *Character) CharacterID {
+	for _, hero := range level.Players {
+		if c == &hero.Character {
+			return hero.CharacterID()
+		}
+	}
+	if monster, exists := level.Monsters[c.Pos]; exists && c == &monster.Character {
+		return monster.CharacterID()
+	}
+	return ""
+}
+
+// TurnEvent is something that happened during a turn, the events of a turn are kept in the order they happened
+type TurnEvent struct {
+	Type GameEvent
+	// Actor is the name of the character acting, Target the name of the character it acted on.
+	// Names are shared by the monsters of a kind, ActorID and TargetID tell which characters they are
+	Actor    string
+	ActorID  CharacterID
+	Target   string
+	TargetID CharacterID
+	// Pos is where the event happened, where the target stands for an attack
+	Pos Pos
+	// Item is the name of the item or spell involved
+	Item     string
+	Damage   int
+	Critical bool
+	// Level is the name of the level entered
+	Level string
+}
+
+// emit adds event to the events of the current turn
+func (level *Level) emit(event TurnEvent) {
+	level.TurnEvents = append(level.TurnEvents, event)
+}
+
+// Subscribe makes handler receive every event of the game once the turn they happened in is over.
+// Handlers are called by the game goroutine and must not keep it waiting
+func (game *Game) Subscribe(handler func(event TurnEvent)) {
+	game.subscribers = append(game.subscribers, handler)
+}
+
+// dispatch sends the events of the turn to the subscribers
+func (game *Game) dispatch() {
+	for _, event := range game.CurrentLevel.TurnEvents {
+		for _, handler := range game.subscribers {
+			handler(event)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package game
+
+import "fmt"
+
+// LevelDef is a step of the player progression read from defs/levels.csv,
+// reaching XP raises the player stats by the level gains
+type LevelDef struct {
+	Level      int
+	XP         int
+	Health     int
+	Mana       int
+	MinDamage  int
+	MaxDamage  int
+	Critical   float64
+	SightRange int
+}
+
+// setXPLevel updates the experience the player needs for its current and next level
+func (game *Game) setXPLevel(p *Player, xpLevel int) {
+	p.XPLevel = xpLevel
+	p.XPForLevel = game.defs.Levels[xpLevel-1].XP
+	p.XPForNextLevel = 0
+	if xpLevel < len(game.defs.Levels) {
+		p.XPForNextLevel = game.defs.Levels[xpLevel].XP
+	}
+}
+
+// gainXP gives xp to the player and raises its level as many times as the thresholds allow
+func (game *Game) gainXP(xp int) {
+	p := game.CurrentLevel.Player
+	p.XP += xp
+	for p.XPForNextLevel != 0 && p.XP >= p.XPForNextLevel {
+		next := game.defs.Levels[p.XPLevel]
+		p.MaxHealth += next.Health
+		p.Health += next.Health
+		p.MaxMana += next.Mana
+		p.Mana += next.Mana
+		p.MinDamage += next.MinDamage
+		p.MaxDamage += next.MaxDamage
+		p.Critical += next.Critical
+		p.SightRange += next.SightRange
+		game.setXPLevel(p, next.Level)
+		game.CurrentLevel.AddMessage(Status, Good, fmt.Sprintf("%s reached level %d", p.Name, p.XPLevel))
+	}
+}
+
+// killMonster removes a monster killed by the player and rewards its experience
+func (game *Game) killMonster(m *Monster) {
+	game.CurrentLevel.emit(TurnEvent{Type: Killed, Actor: game.CurrentLevel.Player.Name, ActorID: game.CurrentLevel.Player.CharacterID(), Target: m.Name, TargetID: m.CharacterID(), Pos: m.Pos})
+	m.Kill(game.CurrentLevel)
+	game.gainXP(m.Def.XP)
+}
@@ -0,0 +1,109 @@
+package game
+
+import "fmt"
+
+// satiation thresholds of the survival mode, the player starts full and loses one point per turn
+const (
+	maxSatiation = 1500
+	hungryAt     = 300
+	weakAt       = 100
+)
+
+// starveDamage is the health lost each turn by a starving player
+const starveDamage = 1
+
+// Food is a consumable eaten to restore the satiation of the player in survival mode
+type Food struct {
+	Entity
+	Size      string
+	Nutrition int
+}
+
+func (f *Food) GetDescription() string {
+	return f.Description
+}
+func (f *Food) GetName() string {
+	return f.Name
+}
+func (f *Food) GetRune() rune {
+	return f.Rune
+}
+func (f *Food) GetEntity() *Entity {
+	return &f.Entity
+}
+func (f *Food) SetPos(pos Pos) {
+	f.Pos = pos
+}
+func (f *Food) GetSize() string {
+	return f.Size
+}
+
+// lootFor keeps the items rolled for the game it can use, food only being eaten in survival mode
+func (game *Game) lootFor(items []Item) []Item {
+	if game.Survival {
+		return items
+	}
+	kept := items[:0]
+	for _, item := range items {
+		if _, isFood := item.(*Food); !isFood {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// Hunger describes how hungry the player is
+func (p *Player) Hunger() string {
+	switch {
+	case p.Satiation == 0:
+		return "Starving"
+	case p.Satiation <= weakAt:
+		return "Weak"
+	case p.Satiation <= hungryAt:
+		return "Hungry"
+	}
+	return "Satiated"
+}
+
+// eat restores the satiation of the player with food from its inventory, refused while the player is full
+func (game *Game) eat(food *Food) bool {
+	level := game.CurrentLevel
+	p := level.Player
+	if p.Satiation >= maxSatiation {
+		level.AddMessage(Status, Info, p.Name+" is not hungry")
+		return false
+	}
+	p.Satiation += food.Nutrition
+	if p.Satiation > maxSatiation {
+		p.Satiation = maxSatiation
+	}
+	game.removeInventoryItem(food, &p.Character)
+	p.ActionPoints -= eatCost
+	level.AddMessage(Status, Good, p.Name+" ate "+food.Name)
+	level.emit(TurnEvent{Type: Eat, Actor: p.Name, ActorID: p.CharacterID(), Item: food.Name, Pos: p.Pos})
+	return true
+}
+
+// updateHunger makes the player of a survival game a bit hungrier, warning it when it gets hungry
+// then starving it once its satiation is over
+func (game *Game) updateHunger() {
+	level := game.CurrentLevel
+	p := level.Player
+	if !level.Survival {
+		return
+	}
+	if p.Satiation == 0 {
+		p.Health -= starveDamage
+		level.AddMessage(Status, Danger, fmt.Sprintf("%s is starving and loses %d", p.Name, starveDamage))
+		return
+	}
+	p.Satiation--
+	switch p.Satiation {
+	case hungryAt:
+		level.AddMessage(Status, Warning, p.Name+" is getting hungry")
+	case weakAt:
+		level.AddMessage(Status, Warning, p.Name+" is weak from hunger")
+	case 0:
+		level.AddMessage(Status, Danger, p.Name+" is starving")
+	}
+}
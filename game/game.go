@@ -1,22 +1,22 @@
 package game
 
 //TODO - improve loadWorld loadLevels - one should call the other one
-//TODO - Save / Load game state
 //TODO - Hero classes + characters interfaces
-//TODO - levels procedural generation
 //TODO - random monsters placed randomly in a level
 //TODO - Chests
 
 import (
 	"bufio"
-	"crypto/rand"
 	"encoding/csv"
 	"fmt"
+	"io/fs"
 	"math"
-	"math/big"
+	"math/rand"
 	"os"
-	"path/filepath"
+	"path"
+	"sort"
 	"strconv"
+	"time"
 )
 
 const (
@@ -34,34 +34,146 @@ const (
 	Drop
 	Restart
 	SetDifficulty
+	LoadGame
+	SelectClass
+	UseAbility
+	CastSpell
+	Fire
+	UpLeft
+	UpRight
+	DownLeft
+	DownRight
+	SetSurvival
+	Follow
+	Join
+	Leave
 )
 
 type Game struct {
 	LevelChans   []chan *Level
 	InputChan    chan *Input
+	ErrorChan    chan error
 	Levels       map[string]*Level
 	CurrentLevel *Level
 	Difficulty   int
+	Seed         int64
+	// Class is the name of the hero class of the next games, the first class of the definitions when empty
+	Class string
+	// Diagonal allows the player and the monsters to move in 8 directions
+	Diagonal bool
+	// Survival makes the player hungry over time, it has to eat to avoid starving
+	Survival bool
+	// NumPlayers is the number of heroes of the party, every viewer follows one of them
+	NumPlayers int
+	// openSeats makes the heroes wait until a viewer joins to play them, joined are the heroes played
+	openSeats bool
+	joined    map[int]bool
+	Data      fs.FS
+	Generator GeneratorConfig
+	// optionErr is an invalid option given to NewGame, the game doesn't start with it
+	optionErr error
+	Turn      int
+	ticks     int
+	// monsterIDs is the ID of the last monster which appeared in the run
+	monsterIDs int
+	// Log keeps the messages of the current run, LogFile is where it is exported when the run ends
+	Log      *MessageLog
+	LogFile  string
+	started  bool
+	defs     *Defs
+	rand     *rand.Rand
+	source   *countingSource
+	recorder *Recorder
+	// snapshots are the last levels sent to the viewers, seen by each hero, follows the hero each viewer follows
+	snapshots   map[int]*Level
+	follows     map[chan *Level]int
+	subscribers []func(event TurnEvent)
 }
 
-func NewGame(numWindows int) *Game {
+// Option configures a Game created by NewGame
+type Option func(*Game)
+
+// WithSeed makes every random decision of the game reproducible
+func WithSeed(seed int64) Option {
+	return func(game *Game) {
+		game.Seed = seed
+	}
+}
+
+// WithClass makes the player a hero of the class named name
+func WithClass(name string) Option {
+	return func(game *Game) {
+		game.Class = name
+	}
+}
+
+// WithDiagonalMoves lets the player and the monsters move diagonally
+func WithDiagonalMoves() Option {
+	return func(game *Game) {
+		game.Diagonal = true
+	}
+}
+
+// WithData loads the levels and the world file from the maps directory of data instead of the embedded ones
+func WithData(data fs.FS) Option {
+	return func(game *Game) {
+		game.Data = data
+	}
+}
+
+func NewGame(numWindows int, options ...Option) *Game {
 	levelChans := make([]chan *Level, numWindows)
 	for i := range levelChans {
 		levelChans[i] = make(chan *Level)
 	}
 	inputChan := make(chan *Input, 10)
+	errorChan := make(chan error, 1)
 
-	game := &Game{LevelChans: levelChans, InputChan: inputChan, Levels: nil, CurrentLevel: nil, Difficulty: 1}
+	game := &Game{LevelChans: levelChans, InputChan: inputChan, ErrorChan: errorChan, Levels: nil, CurrentLevel: nil, Difficulty: 1, NumPlayers: 1, Seed: time.Now().UnixNano(), Data: DefaultData, Generator: DefaultGenerator}
+	for _, option := range options {
+		option(game)
+	}
+	if game.NumPlayers < 1 {
+		game.NumPlayers = 1
+	}
+	// with several heroes each window plays its own
+	game.follows = make(map[chan *Level]int, numWindows)
+	for i, lchan := range levelChans {
+		game.follows[lchan] = i % game.NumPlayers
+	}
+	game.source = newCountingSource(game.Seed)
+	game.rand = rand.New(game.source)
 
 	return game
 }
 
+// WithGenerator changes how the levels below the authored ones are generated,
+// an invalid config is reported when the game starts
+func WithGenerator(config GeneratorConfig) Option {
+	return func(game *Game) {
+		if err := config.Validate(); err != nil {
+			game.optionErr = err
+			return
+		}
+		game.Generator = config
+	}
+}
+
+// NewHeadless builds a game without any window, turns are then played by calling Step
+func NewHeadless(options ...Option) (*Game, error) {
+	game := NewGame(0, options...)
+	if err := game.Restart(); err != nil {
+		return nil, err
+	}
+	game.takeSnapshots()
+	return game, nil
+}
+
 type InputType int
 
 type Tile struct {
 	Rune        rune
 	OverlayRune rune
-	AnimRune    rune
 	Visible     bool
 	Seen        bool
 	Walkable    bool
@@ -71,8 +183,16 @@ type Tile struct {
 type Input struct {
 	Typ          InputType
 	Item         Item
+	ItemRef      *ItemRef
 	LevelChannel chan *Level
 	Difficulty   int
+	Class        string
+	// Player is the ID of the hero playing the input, or followed from now on by the viewer of a Follow input
+	Player int
+	// Spell is the spellbook index of the spell to cast
+	Spell int
+	// Survival is the survival mode chosen by SetSurvival
+	Survival bool
 }
 
 // normal Tiles
@@ -83,13 +203,6 @@ const (
 	Pending   rune = -1
 )
 
-// monsters tiles
-const (
-	Rat    rune = 'R'
-	Spider rune = 'S'
-	Bat    rune = 'B'
-)
-
 // Overlay tiles
 const (
 	ClosedDoor rune = '|'
@@ -105,6 +218,8 @@ const (
 	DownAnim       rune = 'D'
 	UpAnim         rune = 'U'
 	AnimatedPortal rune = 'a'
+	ProjectileAnim rune = 'P'
+	ImpactAnim     rune = 'I'
 )
 
 type Pos struct {
@@ -130,6 +245,8 @@ type Character struct {
 	Entity
 	Health        int
 	MaxHealth     int
+	Mana          int
+	MaxMana       int
 	MinDamage     int
 	MaxDamage     int
 	Armor         int
@@ -140,6 +257,7 @@ type Character struct {
 	EquippedItems []EquipableItem
 	Items         []Item
 	InventorySize int
+	Effects       []*Effect
 }
 
 type GameEvent int
@@ -154,43 +272,68 @@ const (
 	DropItem
 	ConsumePotion
 	OpenChest
+	SpellCast
+	Eat
+	Killed
+	LevelChanged
 )
 
-type GameAttack struct {
-	Damage     int
-	IsCritical bool
-	Who        *Character
-}
-
 type Level struct {
-	Map        [][]Tile
-	Player     *Player
-	Monsters   map[Pos]*Monster
-	Portals    map[Pos]*LevelPos
-	Items      map[Pos][]Item
-	Events     []string
-	EventPos   int
-	Debug      map[Pos]bool
-	LastEvent  GameEvent
-	LastAttack GameAttack
+	// Name is the key of the level in the levels of the game
+	Name string
+	Map  [][]Tile
+	// Player is the hero playing, or the one followed by the viewer of a snapshot. Players are all the heroes of the party
+	Player   *Player
+	Players  []*Player
+	Monsters map[Pos]*Monster
+	Portals  map[Pos]*LevelPos
+	Items    map[Pos][]Item
+	// Log is the message log of the game, shared by all its levels
+	Log   *MessageLog
+	Debug map[Pos]bool
+	// TurnEvents are the events of the last turn, in the order they happened
+	TurnEvents []TurnEvent
+	LastSpell  GameSpell
+	LastShot   GameShot
+	Depth      int
+	// Diagonal tells if the characters of the level can move diagonally
+	Diagonal bool
+	// Survival tells if the player gets hungry
+	Survival bool
+	rand     *rand.Rand
+	// flows are the flow fields leading to the heroes, by goal
+	flows map[Pos]*flowField
+	// views are the fields of view of the heroes on the level, by hero ID
+	views map[int]*fieldOfView
 }
 
+// Pass spends the energy of c waiting for a turn
 func (c *Character) Pass() {
-	c.ActionPoints -= c.Speed
+	c.ActionPoints -= waitCost
 }
 
 func (level *Level) MoveItem(itemToMove Item, character *Character) {
 	pos := character.Pos
 	for i, item := range level.Items[pos] {
 		if item == itemToMove {
+			// ammunition joins the stack already carried without using an inventory slot
+			if ammo, ok := item.(*Ammo); ok && character.ammo(ammo.Name) != nil {
+				level.Items[pos] = append(level.Items[pos][:i], level.Items[pos][i+1:]...)
+				character.ammo(ammo.Name).Count += ammo.Count
+				character.ActionPoints -= pickupCost
+				level.addMessageAt(pos, Loot, Info, fmt.Sprintf("%s picked up %d %s", character.Name, ammo.Count, ammo.Name))
+				level.emit(TurnEvent{Type: Pickup, Actor: character.Name, ActorID: level.characterID(character), Item: ammo.Name, Pos: pos})
+				return
+			}
 			if len(level.Player.Items) < level.Player.InventorySize {
 				level.Items[pos] = append(level.Items[pos][:i], level.Items[pos][i+1:]...)
 				character.Items = append(character.Items, item)
-				level.AddEvent(character.Name + " picked up:" + item.GetName())
-				level.LastEvent = Pickup
+				character.ActionPoints -= pickupCost
+				level.addMessageAt(pos, Loot, Info, character.Name+" picked up:"+item.GetName())
+				level.emit(TurnEvent{Type: Pickup, Actor: character.Name, ActorID: level.characterID(character), Item: item.GetName(), Pos: pos})
 				return
 			} else {
-				level.AddEvent("Inventory full")
+				level.AddMessage(Loot, Warning, "Inventory full")
 				return
 			}
 		}
@@ -198,62 +341,84 @@ func (level *Level) MoveItem(itemToMove Item, character *Character) {
 	panic("Tried to move an item we were not on top of")
 }
 
-func randomizeDamage(min, max int) int {
-	number, err := rand.Int(rand.Reader, big.NewInt(int64(max-min+1)))
-	CheckError(err)
-	return int(number.Int64()) + min
+func randomizeDamage(r *rand.Rand, min, max int) int {
+	return r.Intn(max-min+1) + min
 }
 
-func isCritical(crit float64) bool {
-	number, err := rand.Int(rand.Reader, big.NewInt(100))
-	CheckError(err)
-
-	return float64(number.Int64()) <= crit
+func isCritical(r *rand.Rand, crit float64) bool {
+	return float64(r.Intn(100)) <= crit
 }
 
-func (level *Level) Attack(c1, c2 *Character) {
-	c1.ActionPoints--
-	c1AttackPower := randomizeDamage(c1.MinDamage, c1.MaxDamage)
+// Attack makes c1 hit c2 and returns the damage dealt
+func (level *Level) Attack(c1, c2 *Character) int {
+	c1AttackPower := randomizeDamage(level.rand, c1.MinDamage, c1.MaxDamage)
 	damageDealt := c1AttackPower - c2.Armor
 	if damageDealt < 0 {
 		damageDealt = 0
 	}
 
-	level.LastAttack.Who = c2
-	if isCritical(c1.Critical) {
+	critical := isCritical(level.rand, c1.Critical)
+	if critical {
 		c1AttackPower *= 2
-		level.LastAttack.IsCritical = true
-	} else {
-		level.LastAttack.IsCritical = false
 	}
 	c2.Health -= damageDealt
-	level.LastAttack.Damage = damageDealt
+	level.emit(TurnEvent{Type: Attack, Actor: c1.Name, ActorID: level.characterID(c1), Target: c2.Name, TargetID: level.characterID(c2), Pos: c2.Pos, Damage: damageDealt, Critical: critical})
 
 	if c2.Health > 0 {
-		level.AddEvent(c1.Name + " attacked " + c2.Name + " for " + strconv.Itoa(damageDealt))
+		level.addMessageAt(c2.Pos, Combat, level.harmSeverity(c2), c1.Name+" attacked "+c2.Name+" for "+strconv.Itoa(damageDealt))
+		if critical && damageDealt > 0 {
+			level.addEffect(c2, "bleed")
+		}
+	} else if severity := level.harmSeverity(c2); severity == Danger {
+		level.addMessageAt(c2.Pos, Combat, severity, c1.Name+" killed "+c2.Name)
 	} else {
-		level.AddEvent(c1.Name + " killed " + c2.Name)
+		level.addMessageAt(c2.Pos, Combat, Good, c1.Name+" killed "+c2.Name)
 	}
-	level.LastEvent = Attack
+	return damageDealt
 }
 
+// lineOfSight computes what every hero sees, in its own field of view. The tiles are visible or seen when a hero sees them
 func (level *Level) lineOfSight() {
-	pos := level.Player.Pos
-	dist := level.Player.SightRange
-
-	for y := pos.Y - dist; y <= pos.Y+dist; y++ {
-		for x := pos.X - dist; x <= pos.X+dist; x++ {
-			xDelta := pos.X - x
-			yDelta := pos.Y - y
-			d := math.Sqrt(float64(xDelta*xDelta + yDelta*yDelta))
-			if d <= float64(dist) {
-				level.bresenham(pos, Pos{x, y})
+	for y, row := range level.Map {
+		for x := range row {
+			level.Map[y][x].Visible = false
+		}
+	}
+	for _, hero := range level.Players {
+		view := level.view(hero.ID)
+		for _, row := range view.Visible {
+			for x := range row {
+				row[x] = false
+			}
+		}
+
+		pos := hero.Pos
+		dist := hero.SightRange
+		for y := pos.Y - dist; y <= pos.Y+dist; y++ {
+			for x := pos.X - dist; x <= pos.X+dist; x++ {
+				if distance(pos, Pos{x, y}) <= float64(dist) {
+					level.bresenham(view, pos, Pos{x, y})
+				}
 			}
 		}
 	}
 }
 
-func (level *Level) bresenham(start, end Pos) {
+func (level *Level) bresenham(view *fieldOfView, start, end Pos) {
+	for _, pos := range bresenhamLine(start, end) {
+		level.Map[pos.Y][pos.X].Seen = true
+		level.Map[pos.Y][pos.X].Visible = true
+		view.Seen[pos.Y][pos.X] = true
+		view.Visible[pos.Y][pos.X] = true
+		if !canSeeTrough(level, pos) {
+			return
+		}
+	}
+}
+
+// bresenhamLine returns the positions of the line going from start to end, end excluded
+func bresenhamLine(start, end Pos) []Pos {
+	var line []Pos
 	steep := math.Abs(float64((end.Y)-start.Y)) > math.Abs(float64(end.X-start.X))
 	if steep {
 		start.X, start.Y = start.Y, start.X
@@ -270,16 +435,10 @@ func (level *Level) bresenham(start, end Pos) {
 		deltaX := start.X - end.X
 
 		for x := start.X; x > end.X; x-- {
-			var pos Pos
 			if steep {
-				pos = Pos{y, x}
+				line = append(line, Pos{y, x})
 			} else {
-				pos = Pos{x, y}
-			}
-			level.Map[pos.Y][pos.X].Seen = true
-			level.Map[pos.Y][pos.X].Visible = true
-			if !canSeeTrough(level, pos) {
-				return
+				line = append(line, Pos{x, y})
 			}
 			err += deltaY
 			if 2*err >= deltaX {
@@ -291,16 +450,10 @@ func (level *Level) bresenham(start, end Pos) {
 		deltaX := end.X - start.X
 
 		for x := start.X; x < end.X; x++ {
-			var pos Pos
 			if steep {
-				pos = Pos{y, x}
+				line = append(line, Pos{y, x})
 			} else {
-				pos = Pos{x, y}
-			}
-			level.Map[pos.Y][pos.X].Seen = true
-			level.Map[pos.Y][pos.X].Visible = true
-			if !canSeeTrough(level, pos) {
-				return
+				line = append(line, Pos{x, y})
 			}
 			err += deltaY
 			if 2*err >= deltaX {
@@ -309,16 +462,9 @@ func (level *Level) bresenham(start, end Pos) {
 			}
 		}
 	}
+	return line
 }
 
-func (level *Level) AddEvent(event string) {
-	level.Events[level.EventPos] = event
-	level.EventPos++
-
-	if level.EventPos == len(level.Events) {
-		level.EventPos = 0
-	}
-}
 func inRange(level *Level, pos Pos) bool {
 	return pos.X < len(level.Map[0]) && pos.Y < len(level.Map) && pos.X >= 0 && pos.Y >= 0
 }
@@ -343,12 +489,14 @@ func checkDoor(level *Level, pos Pos) {
 	if level.Map[pos.Y][pos.X].OverlayRune == ClosedDoor {
 		level.Map[pos.Y][pos.X].OverlayRune = OpenDoor
 		level.Map[pos.Y][pos.X].Walkable = true
-		level.LastEvent = DoorOpen
+		level.invalidatePaths()
+		level.emit(TurnEvent{Type: DoorOpen, Actor: level.Player.Name, ActorID: level.Player.CharacterID(), Pos: pos})
 		level.lineOfSight()
 	} else if level.Map[pos.Y][pos.X].OverlayRune == OpenDoor {
 		level.Map[pos.Y][pos.X].OverlayRune = ClosedDoor
 		level.Map[pos.Y][pos.X].Walkable = false
-		level.LastEvent = DoorClose
+		level.invalidatePaths()
+		level.emit(TurnEvent{Type: DoorClose, Actor: level.Player.Name, ActorID: level.Player.CharacterID(), Pos: pos})
 		level.lineOfSight()
 	}
 }
@@ -381,53 +529,148 @@ func (game *Game) pickup(item Item) {
 func (game *Game) Move(to Pos) {
 	level := game.CurrentLevel
 	portal := level.Portals[to]
-	if game.CurrentLevel.Portals[to] != nil {
-		// transfer also events to new level
-		events := game.CurrentLevel.Events
-		eventPos := game.CurrentLevel.EventPos
-
+	if portal == nil && level.Map[to.Y][to.X].OverlayRune == DownStair {
+		var err error
+		if portal, err = game.descend(level, to); err != nil {
+			// the hero stays on the stair, the viewers show why
+			game.reportError(err)
+		}
+	}
+	if portal != nil {
+		// the whole party and the events of the turn go to the new level
+		level.invalidatePaths()
 		game.CurrentLevel = portal.Level
+		game.CurrentLevel.Player = level.Player
+		game.CurrentLevel.Players = level.Players
+		game.CurrentLevel.TurnEvents = level.TurnEvents
 		game.CurrentLevel.Player.Pos = portal.Pos
-		game.CurrentLevel.Events = events
-		game.CurrentLevel.EventPos = eventPos
+		game.CurrentLevel.gatherParty(portal.Pos)
+		game.CurrentLevel.emit(TurnEvent{Type: LevelChanged, Actor: game.CurrentLevel.Player.Name, ActorID: game.CurrentLevel.Player.CharacterID(), Pos: portal.Pos, Level: game.CurrentLevel.Name})
 		game.CurrentLevel.lineOfSight()
 	} else {
 		game.CurrentLevel.Player.Pos = to
-		level.LastEvent = Move
-		for y, row := range game.CurrentLevel.Map {
-			for x := range row {
-				game.CurrentLevel.Map[y][x].Visible = false
-			}
-		}
+		level.emit(TurnEvent{Type: Move, Actor: level.Player.Name, ActorID: level.Player.CharacterID(), Pos: to})
 		game.CurrentLevel.lineOfSight()
 	}
 }
 
-func (game *Game) Restart() {
-	game.Levels = game.loadLevels()
-	game.loadWorld()
+// Restart starts a new run, a run that could not start is a StartError
+func (game *Game) Restart() error {
+	if err := game.restart(); err != nil {
+		return &StartError{Err: err}
+	}
+	return nil
+}
+
+func (game *Game) restart() error {
+	if game.optionErr != nil {
+		return game.optionErr
+	}
+	if err := game.loadDefs(); err != nil {
+		return err
+	}
+	game.exportLog()
+	game.Turn, game.ticks, game.monsterIDs = 0, 0, 0
+	game.Log = &MessageLog{run: game.Log.nextRun()}
+	levels, err := game.loadLevels()
+	if err != nil {
+		return err
+	}
+	start, err := game.loadWorld(levels)
+	if err != nil {
+		return err
+	}
+	game.Levels = levels
+	game.CurrentLevel = start
+	if err := game.newParty(start); err != nil {
+		return err
+	}
 	game.CurrentLevel.lineOfSight()
+	return nil
 }
 
+// newPlayer creates the player of a new game with the selected class
+func (game *Game) newPlayer() (*Player, error) {
+	class := game.defs.Classes[0]
+	if game.Class != "" {
+		class = game.defs.Class(game.Class)
+	}
+	if class == nil {
+		return nil, fmt.Errorf("unknown class %q", game.Class)
+	}
+	player := NewPlayer(game.rand, class)
+	game.setXPLevel(player, 1)
+	return player, nil
+}
+
+// Defs returns the definitions the game is built from, reading them from the game data if needed
+func (game *Game) Defs() (*Defs, error) {
+	if err := game.loadDefs(); err != nil {
+		return nil, err
+	}
+	return game.defs, nil
+}
+
+// loadDefs reads the definitions from the game data the first time they are needed
+func (game *Game) loadDefs() error {
+	if game.defs != nil {
+		return nil
+	}
+	defs, err := LoadDefs(game.Data)
+	if err != nil {
+		return err
+	}
+	game.defs = defs
+	return nil
+}
+
+// autoSave keeps the current session so it can be continued from the start menu, only games of one hero are saved.
+// A failed save is reported to the viewers, the game still ends
+func (game *Game) autoSave() {
+	if game.started && game.NumPlayers == 1 {
+		if err := game.saveToFile(SaveFileName); err != nil {
+			game.reportError(fmt.Errorf("could not save the game: %w", err))
+		}
+	}
+}
+
+// Dead starts a new game after the player died, the events of the turn it died in are kept
 func (game *Game) Dead() {
-	game.Restart()
+	p := game.CurrentLevel.Player
+	events := append(game.CurrentLevel.TurnEvents, TurnEvent{Type: Killed, Target: p.Name, TargetID: p.CharacterID(), Pos: p.Pos})
+	if err := game.Restart(); err != nil {
+		game.reportError(err)
+	}
+	game.CurrentLevel.TurnEvents = events
+}
+
+// reportError tells the viewers the game could not load its levels, without ever blocking the game
+func (game *Game) reportError(err error) {
+	select {
+	case game.ErrorChan <- err:
+	default:
+	}
 }
 
 func (game *Game) resolveMovement(pos Pos) {
 	level := game.CurrentLevel
 	monster, exists := game.CurrentLevel.Monsters[pos]
 	game.CurrentLevel.Player.CameFrom = game.CurrentLevel.Player.Pos
+	if !level.canCutCorner(level.Player.Pos, pos) {
+		level.Player.WantedTo = pos
+		return
+	}
 	if exists {
 		level.Player.WantedTo = pos
+		level.Player.ActionPoints -= attackCost
 		game.CurrentLevel.Attack(&level.Player.Character, &monster.Character)
 		if monster.Health <= 0 {
-			monster.Kill(level)
-		}
-		if game.CurrentLevel.Player.Health <= 0 {
-			game.Dead()
+			game.killMonster(monster)
 		}
-	} else if canWalk(level, pos) {
+		game.checkHeroes()
+	} else if canWalk(level, pos) && level.heroAt(pos) == nil {
 		level.Player.WantedTo = pos
+		level.Player.ActionPoints -= moveCost
 		game.Move(pos)
 	} else {
 		level.Player.WantedTo = pos
@@ -442,39 +685,34 @@ func (game *Game) heal(c *Character, hp int) {
 }
 
 func (game *Game) action(pos Pos, item Item) {
+	p := game.CurrentLevel.Player
 	switch {
 	case game.CurrentLevel.Map[pos.Y][pos.X].OverlayRune == ClosedDoor:
 		checkDoor(game.CurrentLevel, pos)
+		p.ActionPoints -= doorCost
 	case game.CurrentLevel.Map[pos.Y][pos.X].OverlayRune == OpenDoor:
 		checkDoor(game.CurrentLevel, pos)
+		p.ActionPoints -= doorCost
 	case item != nil:
 		switch item.(type) {
 		case ConsumableItem:
-			game.consumePotion(item.(ConsumableItem))
+			game.consume(item.(ConsumableItem))
 		case OpenableItem:
 			game.OpenItem(item.(OpenableItem))
+			p.ActionPoints -= doorCost
 		default:
 		}
 	}
 }
 
+// FrontOf returns the position the player is facing, following its last move
 func (level *Level) FrontOf() Pos {
-	cameFrom := &level.Player.CameFrom
-	currentPos := &level.Player.Pos
-	switch {
-	case cameFrom.X < currentPos.X:
-		return Pos{currentPos.X + 1, currentPos.Y}
-	case cameFrom.X > currentPos.X:
-		return Pos{currentPos.X - 1, currentPos.Y}
-	case cameFrom.Y < currentPos.Y:
-		return Pos{currentPos.X, currentPos.Y + 1}
-	case cameFrom.Y > currentPos.Y:
-		return Pos{currentPos.X, currentPos.Y - 1}
-	case *cameFrom == *currentPos:
+	cameFrom := level.Player.CameFrom
+	currentPos := level.Player.Pos
+	if cameFrom == currentPos {
 		return level.Player.WantedTo
-	default:
-		return Pos{}
 	}
+	return Pos{currentPos.X + sign(currentPos.X-cameFrom.X), currentPos.Y + sign(currentPos.Y-cameFrom.Y)}
 }
 
 func (game *Game) removeInventoryItem(itemToRemove Item, character *Character) {
@@ -492,16 +730,52 @@ func (game *Game) dropItem(itemToDrop Item, character *Character) {
 		if item == itemToDrop {
 			character.Items = append(game.CurrentLevel.Player.Items[:i], game.CurrentLevel.Player.Items[i+1:]...)
 			game.CurrentLevel.Items[character.Pos] = append(game.CurrentLevel.Items[character.Pos], itemToDrop)
-			game.CurrentLevel.AddEvent(character.Name + " dropped " + itemToDrop.GetName())
-			game.CurrentLevel.LastEvent = DropItem
+			game.CurrentLevel.addMessageAt(character.Pos, Loot, Info, character.Name+" dropped "+itemToDrop.GetName())
+			game.CurrentLevel.emit(TurnEvent{Type: DropItem, Actor: character.Name, ActorID: game.CurrentLevel.characterID(character), Item: itemToDrop.GetName(), Pos: character.Pos})
+			character.ActionPoints -= dropCost
 			return
 		}
 	}
 	panic("Tried to drop bad item")
 }
 
+// canUse tells if the item of input is one the player can use that way from where it lies,
+// inputs of remote players may name any item
+func (level *Level) canUse(input *Input) bool {
+	var where string
+	if input.Item != nil {
+		ref := level.itemRef(input.Item)
+		if ref == nil {
+			return false
+		}
+		where = ref.Where
+	}
+	switch input.Typ {
+	case Equip:
+		_, ok := input.Item.(EquipableItem)
+		return ok && (where == InInventory || where == InEquipped)
+	case Drop:
+		return input.Item != nil && where == InInventory
+	case TakeItem:
+		return input.Item == nil || where == OnGround
+	case Action:
+		switch input.Item.(type) {
+		case ConsumableItem:
+			return where == InInventory
+		case OpenableItem:
+			return where == InFront
+		}
+	}
+	return true
+}
+
 func (game *Game) handleInput(input *Input) {
 	p := game.CurrentLevel.Player
+	if input.Typ.takesTurn() && p.Effect("stun") != nil {
+		game.CurrentLevel.AddMessage(Status, Warning, p.Name+" is stunned")
+		p.Pass()
+		return
+	}
 	switch input.Typ {
 	case Up:
 		newPos := Pos{p.X, p.Y - 1}
@@ -515,6 +789,12 @@ func (game *Game) handleInput(input *Input) {
 	case Right:
 		newPos := Pos{p.X + 1, p.Y}
 		game.resolveMovement(newPos)
+	case UpLeft, UpRight, DownLeft, DownRight:
+		if !game.CurrentLevel.Diagonal {
+			return
+		}
+		delta := diagonals[input.Typ]
+		game.resolveMovement(Pos{p.X + delta.X, p.Y + delta.Y})
 	case Action:
 		game.action(game.CurrentLevel.FrontOf(), input.Item)
 	case TakeAll:
@@ -529,199 +809,291 @@ func (game *Game) handleInput(input *Input) {
 		}
 	case SetDifficulty:
 		game.Difficulty = input.Difficulty
+	case SetSurvival:
+		game.Survival = input.Survival
+	case SelectClass:
+		if game.defs.Class(input.Class) != nil {
+			game.Class = input.Class
+		}
+	case UseAbility:
+		game.useAbility()
+	case CastSpell:
+		game.castSpell(input.Spell)
+	case Fire:
+		game.fire()
 	case Drop:
 		game.dropItem(input.Item, &game.CurrentLevel.Player.Character)
 	case Restart:
-		game.Restart()
-	case CloseWindow:
-		close(input.LevelChannel)
-		chanIndex := 0
-		for i, c := range game.LevelChans {
-			if c == input.LevelChannel {
-				chanIndex = i
-				break
+		if err := game.Restart(); err != nil {
+			game.reportError(err)
+			return
+		}
+		game.started = true
+	case LoadGame:
+		if err := game.loadFromFile(SaveFileName); err != nil {
+			if err := game.Restart(); err != nil {
+				game.reportError(err)
+				return
 			}
+			game.CurrentLevel.AddMessage(General, Warning, "Could not load saved game")
 		}
-		game.LevelChans = append(game.LevelChans[:chanIndex], game.LevelChans[chanIndex+1:]...)
+		game.CurrentLevel.lineOfSight()
+		game.started = true
+	case Follow:
+		game.Follow(input.LevelChannel, input.Player)
+	case Join:
+		game.join(input.LevelChannel, input.Player)
+	case Leave:
+		game.leave(input.LevelChannel)
+	case CloseWindow:
+		game.leave(input.LevelChannel)
 		if len(game.LevelChans) == 0 {
+			game.autoSave()
+			game.exportLog()
 			os.Exit(1)
 		}
 	}
 }
 
-func (game *Game) loadWorld() {
-	file, err := os.Open("game/maps/world.txt")
-	CheckError(err)
+// loadWorld links the levels together with the portals of the world file and returns the starting level
+func (game *Game) loadWorld(levels map[string]*Level) (*Level, error) {
+	fileName := path.Join(mapDir, "world.txt")
+	file, err := game.Data.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
 
 	csvReader := csv.NewReader(file)
 	csvReader.FieldsPerRecord = -1
 	csvReader.TrimLeadingSpace = true
 	rows, err := csvReader.ReadAll()
-	CheckError(err)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fileName, err)
+	}
+	if len(rows) == 0 {
+		return nil, &WorldError{File: fileName, Line: 1, Reason: "missing starting level"}
+	}
 
+	var errs LoadErrors
+	var start *Level
 	for rIndex, row := range rows {
+		line := rIndex + 1
 		if rIndex == 0 {
-			game.CurrentLevel = game.Levels[row[0]]
-			if game.CurrentLevel == nil {
-				fmt.Println("could'nt find level in world file")
-				panic(nil)
+			start = levels[row[0]]
+			if start == nil {
+				errs = append(errs, &WorldError{File: fileName, Line: line, Level: row[0], Reason: fmt.Sprintf("unknown level %q", row[0])})
 			}
 			continue
 		}
-		levelWithPortal := game.Levels[row[0]]
+		if len(row) != 6 {
+			errs = append(errs, &WorldError{File: fileName, Line: line, Reason: fmt.Sprintf("expected 6 fields, got %d", len(row))})
+			continue
+		}
+
+		levelWithPortal := levels[row[0]]
 		if levelWithPortal == nil {
-			fmt.Println("could'nt find level in world file")
-			panic(nil)
+			errs = append(errs, &WorldError{File: fileName, Line: line, Level: row[0], Reason: fmt.Sprintf("unknown level %q", row[0])})
+		}
+		levelToTeleport := levels[row[3]]
+		if levelToTeleport == nil {
+			errs = append(errs, &WorldError{File: fileName, Line: line, Level: row[3], Reason: fmt.Sprintf("unknown level %q", row[3])})
 		}
-		x, err := strconv.Atoi(row[1])
-		CheckError(err)
-		y, err := strconv.Atoi(row[2])
-		CheckError(err)
 
-		pos := Pos{x, y}
+		pos, err := parsePortalPos(fileName, line, row[0], row[1], row[2], levelWithPortal)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		posToTeleport, err := parsePortalPos(fileName, line, row[3], row[4], row[5], levelToTeleport)
+		if err != nil {
+			errs = append(errs, err)
+		}
 
-		levelToTeleport := row[3]
+		if levelWithPortal != nil && levelToTeleport != nil {
+			levelWithPortal.Portals[pos] = &LevelPos{
+				Level: levelToTeleport,
+				Pos:   posToTeleport,
+			}
+		}
+	}
 
-		x, err = strconv.Atoi(row[4])
-		CheckError(err)
-		y, err = strconv.Atoi(row[5])
-		CheckError(err)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return start, nil
+}
 
-		posToTeleport := Pos{x, y}
+func parsePortalPos(fileName string, line int, levelName, xField, yField string, level *Level) (Pos, error) {
+	x, err := strconv.Atoi(xField)
+	if err != nil {
+		return Pos{}, &WorldError{File: fileName, Line: line, Level: levelName, Reason: fmt.Sprintf("invalid portal coordinates %s,%s", xField, yField), Err: err}
+	}
+	y, err := strconv.Atoi(yField)
+	if err != nil {
+		return Pos{}, &WorldError{File: fileName, Line: line, Level: levelName, Reason: fmt.Sprintf("invalid portal coordinates %s,%s", xField, yField), Err: err}
+	}
 
-		levelWithPortal.Portals[pos] = &LevelPos{
-			Level: game.Levels[levelToTeleport],
-			Pos:   posToTeleport,
-		}
+	pos := Pos{x, y}
+	if level != nil && !inRange(level, pos) {
+		return Pos{}, &WorldError{File: fileName, Line: line, Level: levelName, Reason: fmt.Sprintf("portal coordinates %d,%d are outside of level %s", x, y, levelName)}
 	}
+	return pos, nil
 }
 
-func (game *Game) loadLevels() map[string]*Level {
-	player := NewPlayer()
+func (game *Game) newLevel(player *Player, width, height int) *Level {
+	level := &Level{}
+	level.rand = game.rand
+	level.Diagonal = game.Diagonal
+	level.Survival = game.Survival
+	level.Log = game.Log
+	level.Player = player
+	level.Players = []*Player{player}
+	level.Map = make([][]Tile, height)
+	level.Monsters = make(map[Pos]*Monster, 0)
+	level.Portals = make(map[Pos]*LevelPos, 0)
+	level.Items = make(map[Pos][]Item, 0)
+
+	for i := range level.Map {
+		level.Map[i] = make([]Tile, width)
+	}
+	return level
+}
+
+func (game *Game) loadLevels() (map[string]*Level, error) {
+	player, err := game.newPlayer()
+	if err != nil {
+		return nil, err
+	}
 
 	levels := make(map[string]*Level, 0)
 
-	fileNames, err := filepath.Glob("game/maps/*.map")
-	CheckError(err)
+	fileNames, err := fs.Glob(game.Data, path.Join(mapDir, "*.map"))
+	if err != nil {
+		return nil, err
+	}
 
+	var errs LoadErrors
 	for _, fileName := range fileNames {
-		levelName := filepath.Base(fileName[:len(fileName)-len(filepath.Ext(fileName))])
-
-		file, err := os.Open(fileName)
-		CheckError(err)
-
-		scanner := bufio.NewScanner(file)
-		levelLines := make([]string, 0)
-		longestRow := 0
-		index := 0
-		for scanner.Scan() {
-			levelLines = append(levelLines, scanner.Text())
-			if len(levelLines[index]) > longestRow {
-				longestRow = len(levelLines[index])
+		levelName := path.Base(fileName[:len(fileName)-len(path.Ext(fileName))])
+
+		level, _, err := game.parseLevel(fileName, player)
+		if err != nil {
+			if mapErrs, ok := err.(LoadErrors); ok {
+				errs = append(errs, mapErrs...)
+			} else {
+				errs = append(errs, err)
 			}
-			index++
-		}
-
-		level := &Level{}
-		level.Events = make([]string, 15)
-		level.Player = player
-		level.Map = make([][]Tile, len(levelLines))
-		level.Monsters = make(map[Pos]*Monster, 0)
-		level.Portals = make(map[Pos]*LevelPos, 0)
-		level.Items = make(map[Pos][]Item, 0)
-		level.LastEvent = -1
-
-		for i := range level.Map {
-			level.Map[i] = make([]Tile, longestRow)
-		}
-
-		for y := range levelLines {
-			line := levelLines[y]
-			for x, c := range line {
-				pos := Pos{X: x, Y: y}
-				level.Map[y][x].Walkable = true
-				level.Map[y][x].Actionable = false
-				level.Map[y][x].AnimRune = Blank
-				switch c {
-				case ' ', '\n', '\t', '\r':
-					level.Map[y][x].Rune = Blank
-					level.Map[y][x].Walkable = false
-				case '#':
-					level.Map[y][x].Rune = StoneWall
-					level.Map[y][x].Walkable = false
-				case '.':
-					level.Map[y][x].Rune = DirtFloor
-				case '|':
-					level.Map[y][x].OverlayRune = ClosedDoor
-					level.Map[y][x].Rune = Pending
-					level.Map[y][x].Walkable = false
-					level.Map[y][x].Actionable = true
-				case '/':
-					level.Map[y][x].OverlayRune = OpenDoor
-					level.Map[y][x].Rune = Pending
-					level.Map[y][x].Actionable = true
-				case 'd':
-					level.Map[y][x].OverlayRune = DownStair
-					level.Map[y][x].Rune = Pending
-				case 'u':
-					level.Map[y][x].OverlayRune = UpStair
-					level.Map[y][x].Rune = Pending
-				case 's':
-					level.Items[pos] = append(level.Items[pos], NewSword(pos))
-					level.Map[y][x].Rune = Pending
-				//case 'B':
-				//	level.Items[pos] = append(level.Items[pos], NewSword(pos))
-				//	level.Map[y][x].Rune = Pending
-				case 'h':
-					level.Items[pos] = append(level.Items[pos], NewHelmet(pos))
-					level.Map[y][x].Rune = Pending
-				case 'b':
-					level.Items[pos] = append(level.Items[pos], NewBoots(pos))
-					level.Map[y][x].Rune = Pending
-				case 't':
-					level.Items[pos] = append(level.Items[pos], NewTreasureChest(pos, 3))
-					level.Map[y][x].Rune = Pending
-					level.Map[y][x].Walkable = false
-					level.Map[y][x].Actionable = true
-				case 'a':
-					level.Items[pos] = append(level.Items[pos], NewPlate(pos))
-					level.Map[y][x].Rune = Pending
-				case 'p':
-					level.Items[pos] = append(level.Items[pos], NewHealthPotion(pos, "Small"))
-					level.Map[y][x].Rune = Pending
-				case '@':
-					level.Player.Pos = pos
-					level.Map[y][x].Rune = Pending
-				case 'B':
-					level.Monsters[pos] = NewBat(pos)
-					level.Map[y][x].Rune = Pending
-				case 'R':
-					level.Monsters[pos] = NewRat(pos)
+			continue
+		}
+
+		game.randomizeLevel(level)
+		level.Name = levelName
+		levels[levelName] = level
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return levels, nil
+}
+
+// parseLevel builds a level from a map file and returns where the player can start in it.
+// Every invalid character is reported as a MapError, the level is still returned with blanks in their place
+func (game *Game) parseLevel(fileName string, player *Player) (*Level, []Pos, error) {
+	file, err := game.Data.Open(fileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	levelLines := make([]string, 0)
+	longestRow := 0
+	index := 0
+	for scanner.Scan() {
+		levelLines = append(levelLines, scanner.Text())
+		if len(levelLines[index]) > longestRow {
+			longestRow = len(levelLines[index])
+		}
+		index++
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", fileName, err)
+	}
+
+	level := game.newLevel(player, longestRow, len(levelLines))
+
+	starts := make([]Pos, 0, 1)
+	var errs LoadErrors
+	for y := range levelLines {
+		line := levelLines[y]
+		for x, c := range line {
+			pos := Pos{X: x, Y: y}
+			level.Map[y][x].Walkable = true
+			level.Map[y][x].Actionable = false
+			switch c {
+			case ' ', '\n', '\t', '\r':
+				level.Map[y][x].Rune = Blank
+				level.Map[y][x].Walkable = false
+			case '#':
+				level.Map[y][x].Rune = StoneWall
+				level.Map[y][x].Walkable = false
+			case '.':
+				level.Map[y][x].Rune = DirtFloor
+			case '|':
+				level.Map[y][x].OverlayRune = ClosedDoor
+				level.Map[y][x].Rune = Pending
+				level.Map[y][x].Walkable = false
+				level.Map[y][x].Actionable = true
+			case '/':
+				level.Map[y][x].OverlayRune = OpenDoor
+				level.Map[y][x].Rune = Pending
+				level.Map[y][x].Actionable = true
+			case 'd':
+				level.Map[y][x].OverlayRune = DownStair
+				level.Map[y][x].Rune = Pending
+			case 'u':
+				level.Map[y][x].OverlayRune = UpStair
+				level.Map[y][x].Rune = Pending
+			case 't':
+				level.Items[pos] = append(level.Items[pos], game.newChest(pos, 3))
+				level.Map[y][x].Rune = Pending
+				level.Map[y][x].Walkable = false
+				level.Map[y][x].Actionable = true
+			case '@':
+				level.Player.Pos = pos
+				level.Map[y][x].Rune = Pending
+				starts = append(starts, pos)
+			default:
+				if def, exists := game.defs.monstersByGlyph[c]; exists {
+					level.Monsters[pos] = game.newMonster(def, pos)
 					level.Map[y][x].Rune = Pending
-				case 'S':
-					level.Monsters[pos] = NewSpider(pos)
+					continue
+				}
+				if loot, exists := game.defs.Placements[c]; exists {
+					level.Items[pos] = append(level.Items[pos], game.lootFor(loot.Roll(game.rand, pos))...)
 					level.Map[y][x].Rune = Pending
-				default:
-					panic("invalid character in map")
+					continue
 				}
+				errs = append(errs, &MapError{File: fileName, Line: y + 1, Column: x + 1, Rune: c})
+				level.Map[y][x].Rune = Blank
+				level.Map[y][x].Walkable = false
 			}
 		}
+	}
 
-		for y, row := range level.Map {
-			for x, tile := range row {
-				if tile.Rune == Pending {
-					level.Map[y][x].Rune = level.bfsFloor(Pos{x, y})
-				}
+	for y, row := range level.Map {
+		for x, tile := range row {
+			if tile.Rune == Pending {
+				level.Map[y][x].Rune = level.bfsFloor(Pos{x, y})
 			}
 		}
-
-		game.randomizeLevel(level)
-		levels[levelName] = level
-		err = file.Close()
-		CheckError(err)
 	}
 
-	return levels
+	if len(errs) > 0 {
+		return level, starts, errs
+	}
+	return level, starts, nil
 }
 
 func countValidPositions(level *Level) int {
@@ -740,29 +1112,17 @@ func countValidPositions(level *Level) int {
 
 func (game *Game) randomizeLevel(level *Level) {
 	numChests := countValidPositions(level) * game.Difficulty / 100
-	randomizeChests(numChests, level)
+	game.randomizeChests(numChests, level)
 	numMonsters := countValidPositions(level) * game.Difficulty / 100
-	randomizeMonsters(numMonsters, level)
+	game.randomizeMonsters(numMonsters, level)
 }
 
 func getNeighbors(level *Level, pos Pos) []Pos {
-	neighbors := make([]Pos, 0, 4)
-	left := Pos{pos.X - 1, pos.Y}
-	right := Pos{pos.X + 1, pos.Y}
-	up := Pos{pos.X, pos.Y - 1}
-	down := Pos{pos.X, pos.Y + 1}
-
-	if canWalk(level, right) {
-		neighbors = append(neighbors, right)
-	}
-	if canWalk(level, left) {
-		neighbors = append(neighbors, left)
-	}
-	if canWalk(level, up) {
-		neighbors = append(neighbors, up)
-	}
-	if canWalk(level, down) {
-		neighbors = append(neighbors, down)
+	neighbors := make([]Pos, 0, 8)
+	for _, next := range level.adjacent(pos) {
+		if canWalk(level, next) {
+			neighbors = append(neighbors, next)
+		}
 	}
 	return neighbors
 }
@@ -832,13 +1192,11 @@ func (level *Level) astar(start Pos, goal Pos) []Pos {
 		}
 
 		for _, next := range getNeighbors(level, current) {
-			newCost := costSoFar[current] + 1
+			newCost := costSoFar[current] + stepCost(current, next)
 			_, exists := costSoFar[next]
 			if !exists || newCost < costSoFar[next] {
 				costSoFar[next] = newCost
-				xDist := int(math.Abs(float64(goal.X - next.X)))
-				yDist := int(math.Abs(float64(goal.Y - next.Y)))
-				priority := newCost + xDist + yDist
+				priority := newCost + level.heuristic(next, goal)
 				frontier = frontier.push(next, priority)
 				//level.Debug[next] = true
 				cameFrom[next] = current
@@ -848,7 +1206,7 @@ func (level *Level) astar(start Pos, goal Pos) []Pos {
 	return nil
 }
 
-func findValidPosition(level *Level) Pos {
+func findValidPosition(r *rand.Rand, level *Level) Pos {
 	posList := make([]Pos, 0)
 	for y := range level.Map {
 		line := level.Map[y]
@@ -859,98 +1217,154 @@ func findValidPosition(level *Level) Pos {
 			}
 		}
 	}
-	randIndex, err := rand.Int(rand.Reader, big.NewInt(int64(len(posList))))
-	CheckError(err)
-	return posList[randIndex.Int64()]
+	return posList[r.Intn(len(posList))]
 }
 
-func randomChest() int {
-	randIndex, err := rand.Int(rand.Reader, big.NewInt(100))
-	CheckError(err)
+func randomChest(r *rand.Rand) int {
+	randIndex := r.Intn(100)
 
 	switch {
-	case randIndex.Int64() < 2:
+	case randIndex < 2:
 		return 8
-	case randIndex.Int64() < 5:
+	case randIndex < 5:
 		return 7
-	case randIndex.Int64() < 7:
+	case randIndex < 7:
 		return 6
-	case randIndex.Int64() < 15:
+	case randIndex < 15:
 		return 5
-	case randIndex.Int64() < 20:
+	case randIndex < 20:
 		return 4
-	case randIndex.Int64() < 30:
+	case randIndex < 30:
 		return 3
-	case randIndex.Int64() < 40:
+	case randIndex < 40:
 		return 2
-	case randIndex.Int64() <= 100:
+	case randIndex <= 100:
 		return 1
 	}
 	return 0
 }
 
-func randomizeChests(numChests int, level *Level) {
+func (game *Game) randomizeChests(numChests int, level *Level) {
 	for i := 0; i < numChests; i++ {
-		randPos := findValidPosition(level)
-		randSize := randomChest()
-		level.Items[randPos] = append(level.Items[randPos], NewTreasureChest(randPos, randSize))
+		randPos := findValidPosition(game.rand, level)
+		randSize := randomChest(game.rand)
+		level.Items[randPos] = append(level.Items[randPos], game.newChest(randPos, randSize))
 		level.Map[randPos.Y][randPos.X].Walkable = false
 		level.Map[randPos.Y][randPos.X].Actionable = true
 	}
 }
 
-func randomMonster(p Pos) *Monster {
-	number, err := rand.Int(rand.Reader, big.NewInt(3))
-	CheckError(err)
-
-	switch {
-	case number.Int64() == 0:
-		return NewBat(p)
-	case number.Int64() == 1:
-		return NewSpider(p)
-	case number.Int64() == 2:
-		return NewRat(p)
+func (game *Game) randomizeMonsters(numMonsters int, level *Level) {
+	for i := 0; i < numMonsters; i++ {
+		def := game.defs.randomMonsterDef(game.rand, game.Difficulty, level.Depth)
+		if def == nil {
+			return
+		}
+		randPos := findValidPosition(game.rand, level)
+		level.Monsters[randPos] = game.newMonster(def, randPos)
 	}
-	return NewRat(p)
 }
 
-func randomizeMonsters(numMonsters int, level *Level) {
-	for i := 0; i < numMonsters; i++ {
-		randPos := findValidPosition(level)
-		level.Monsters[randPos] = randomMonster(randPos)
+// newMonster creates a monster of def standing on pos with the next monster ID of the run
+func (game *Game) newMonster(def *MonsterDef, pos Pos) *Monster {
+	monster := NewMonster(game.rand, def, pos)
+	monster.Items = game.lootFor(monster.Items)
+	game.monsterIDs++
+	monster.ID = game.monsterIDs
+	return monster
+}
+
+// sortedMonsters returns the level monsters in a stable order so turns can be replayed
+func (level *Level) sortedMonsters() []*Monster {
+	monsters := make([]*Monster, 0, len(level.Monsters))
+	for _, monster := range level.Monsters {
+		monsters = append(monsters, monster)
 	}
+	sort.Slice(monsters, func(i, j int) bool {
+		if monsters[i].Y != monsters[j].Y {
+			return monsters[i].Y < monsters[j].Y
+		}
+		return monsters[i].X < monsters[j].X
+	})
+	return monsters
 }
 
-func (game *Game) Run() {
-	game.Levels = game.loadLevels()
-	game.loadWorld()
-	game.CurrentLevel.lineOfSight()
+// Step synchronously plays one turn, the player input then the monsters, and returns the snapshot
+// the hero of the input gets, the same one its viewer is sent
+func (game *Game) Step(input *Input) *Level {
+	game.play(input)
+	game.takeSnapshots()
+	return game.snapshotOf(input.Player)
+}
 
-	for _, lchan := range game.LevelChans {
-		lchan <- game.CurrentLevel
+// play plays input of its hero then the monsters until a hero can act again
+func (game *Game) play(input *Input) {
+	// the hero of the input plays it
+	if hero := game.CurrentLevel.hero(input.Player); hero != nil {
+		game.CurrentLevel.Player = hero
 	}
-
-	for input := range game.InputChan {
-		if input.Typ == QuitGame {
+	// viewers pick items in their snapshot, they are found back at the same place in the live level
+	if snapshot := game.snapshots[input.Player]; input.Item != nil && snapshot != nil && game.CurrentLevel.itemRef(input.Item) == nil {
+		input.ItemRef = snapshot.itemRef(input.Item)
+		input.Item = nil
+		if input.ItemRef == nil {
+			// picked in an older snapshot, the viewer will play again on the current one
 			return
 		}
-		game.handleInput(input)
-
-		for _, monster := range game.CurrentLevel.Monsters {
-			monster.Update(game)
-			if game.CurrentLevel.Player.Health <= 0 {
-				game.Dead()
-				break
-			}
+	}
+	if game.recorder != nil {
+		if err := game.recorder.record(game, input); err != nil {
+			// the session goes on without being recorded
+			game.reportError(fmt.Errorf("could not record the game: %w", err))
+			game.recorder.close()
+			game.recorder = nil
 		}
-
-		if len(game.LevelChans) == 0 {
+	}
+	if input.Item == nil && input.ItemRef != nil {
+		input.Item = game.CurrentLevel.resolveItemRef(input.ItemRef)
+		if input.Item == nil {
+			game.CurrentLevel.AddMessage(Loot, Warning, "The item is gone")
 			return
 		}
-
-		for _, lchan := range game.LevelChans {
-			lchan <- game.CurrentLevel
+	}
+	game.CurrentLevel.LastSpell = GameSpell{}
+	game.CurrentLevel.LastShot = GameShot{}
+	game.CurrentLevel.TurnEvents = nil
+	// a loaded game may give the turn to the player before it has the energy to act
+	game.waitForPlayers()
+	if input.Typ.isHeroAction() {
+		// a dead hero doesn't play anymore and the others wait for the heroes which can still act
+		hero := game.CurrentLevel.hero(input.Player)
+		if hero == nil || !hero.Ready() {
+			return
 		}
+		game.CurrentLevel.Player = hero
+	}
+	if !game.CurrentLevel.canUse(input) {
+		game.CurrentLevel.AddMessage(Loot, Warning, "That item can't be used this way")
+		return
+	}
+	game.handleInput(input)
+	game.waitForPlayers()
+	game.dispatch()
+}
 
+func (game *Game) Run() {
+	if err := game.Restart(); err != nil {
+		game.reportError(err)
+		return
+	}
+
+	game.takeSnapshots()
+	game.publish()
+
+	for input := range game.InputChan {
+		if input.Typ == QuitGame {
+			game.autoSave()
+			game.exportLog()
+			return
+		}
+		game.Step(input)
+		game.publish()
 	}
 }
@@ -0,0 +1,136 @@
+package game
+
+import (
+	"testing"
+)
+
+// floor makes pos an empty floor tile
+func floor(level *Level, pos Pos) {
+	level.Map[pos.Y][pos.X] = Tile{Rune: DirtFloor, Walkable: true}
+	delete(level.Items, pos)
+	delete(level.Portals, pos)
+	delete(level.Monsters, pos)
+}
+
+func TestStep(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup changes the live level around start, the hero is free to move to right
+		setup  func(game *Game, start, right Pos)
+		inputs func(snapshot *Level) []*Input
+		check  func(t *testing.T, snapshot *Level, start, right Pos)
+	}{
+		{
+			name:   "move",
+			setup:  func(game *Game, start, right Pos) {},
+			inputs: func(*Level) []*Input { return []*Input{{Typ: Right}} },
+			check: func(t *testing.T, snapshot *Level, start, right Pos) {
+				if snapshot.Player.Pos != right {
+					t.Errorf("hero at %v, want %v", snapshot.Player.Pos, right)
+				}
+			},
+		},
+		{
+			name: "open door",
+			setup: func(game *Game, start, right Pos) {
+				game.CurrentLevel.Map[right.Y][right.X] = Tile{Rune: DirtFloor, OverlayRune: ClosedDoor, Actionable: true}
+			},
+			// the hero bumps into the door then opens the one it faces
+			inputs: func(*Level) []*Input { return []*Input{{Typ: Right}, {Typ: Action}} },
+			check: func(t *testing.T, snapshot *Level, start, right Pos) {
+				if tile := snapshot.Map[right.Y][right.X]; tile.OverlayRune != OpenDoor || !tile.Walkable {
+					t.Errorf("door is %q, want it open", tile.OverlayRune)
+				}
+				if snapshot.Player.Pos != start {
+					t.Errorf("hero at %v, want it to stay at %v", snapshot.Player.Pos, start)
+				}
+			},
+		},
+		{
+			name: "pick up",
+			setup: func(game *Game, start, right Pos) {
+				game.CurrentLevel.Items[start] = []Item{NewItem(game.rand, game.defs.Items["Elixir"], start)}
+			},
+			inputs: func(snapshot *Level) []*Input {
+				return []*Input{{Typ: TakeItem, Item: snapshot.Items[snapshot.Player.Pos][0]}}
+			},
+			check: func(t *testing.T, snapshot *Level, start, right Pos) {
+				if len(snapshot.Items[start]) != 0 {
+					t.Errorf("%d items left on the ground, want none", len(snapshot.Items[start]))
+				}
+				items := snapshot.Player.Items
+				if len(items) == 0 || items[len(items)-1].GetName() != "Elixir" {
+					t.Error("the hero doesn't carry the elixir")
+				}
+			},
+		},
+		{
+			name: "eat",
+			setup: func(game *Game, start, right Pos) {
+				game.Survival, game.CurrentLevel.Survival = true, true
+				p := game.CurrentLevel.Player
+				p.Satiation = hungryAt
+				p.Items = append(p.Items, NewItem(game.rand, game.defs.Items["Ration"], Pos{}))
+			},
+			inputs: func(snapshot *Level) []*Input {
+				items := snapshot.Player.Items
+				return []*Input{{Typ: Action, Item: items[len(items)-1]}}
+			},
+			check: func(t *testing.T, snapshot *Level, start, right Pos) {
+				if snapshot.Player.Satiation <= hungryAt {
+					t.Errorf("satiation is %d, want more than %d", snapshot.Player.Satiation, hungryAt)
+				}
+				for _, item := range snapshot.Player.Items {
+					if item.GetName() == "Ration" {
+						t.Error("the ration wasn't eaten")
+					}
+				}
+			},
+		},
+		{
+			name: "attack",
+			setup: func(game *Game, start, right Pos) {
+				monster := game.newMonster(game.defs.Monsters[0], right)
+				monster.Health, monster.MaxHealth, monster.Armor = 1000, 1000, 0
+				game.CurrentLevel.Monsters[right] = monster
+			},
+			inputs: func(*Level) []*Input { return []*Input{{Typ: Right}} },
+			check: func(t *testing.T, snapshot *Level, start, right Pos) {
+				monster := snapshot.Monsters[right]
+				if monster == nil || monster.Health >= 1000 {
+					t.Error("the monster wasn't hit")
+				}
+				if snapshot.Player.Pos != start {
+					t.Errorf("hero at %v, want it to stay at %v", snapshot.Player.Pos, start)
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			game, err := NewHeadless(WithSeed(1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			level := game.CurrentLevel
+			// the other monsters are left out so only the tested action happens
+			level.Monsters = make(map[Pos]*Monster)
+			start := level.Player.Pos
+			right := Pos{start.X + 1, start.Y}
+			floor(level, right)
+			test.setup(game, start, right)
+			level.lineOfSight()
+			game.takeSnapshots()
+
+			snapshot := game.snapshotOf(level.Player.ID)
+			for _, input := range test.inputs(snapshot) {
+				snapshot = game.Step(input)
+			}
+			if snapshot == game.CurrentLevel || snapshot.Player == game.CurrentLevel.Player {
+				t.Fatal("Step returned the live level instead of a snapshot")
+			}
+			test.check(t, snapshot, start, right)
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// GeneratorConfig describes the levels built by the dungeon generator
+type GeneratorConfig struct {
+	Width, Height int
+	// MinRoomSize and MaxRoomSize bound the inner size of the rooms
+	MinRoomSize, MaxRoomSize int
+	MaxRooms                 int
+	// RoomDensity is the part of the level, between 0 and 1, the rooms try to fill
+	RoomDensity float64
+	// DoorChance is the probability, between 0 and 1, of a closed door where a corridor enters a room
+	DoorChance float64
+}
+
+var DefaultGenerator = GeneratorConfig{
+	Width:       60,
+	Height:      30,
+	MinRoomSize: 4,
+	MaxRoomSize: 10,
+	MaxRooms:    12,
+	RoomDensity: 0.35,
+	DoorChance:  0.5,
+}
+
+const maxGeneratorAttempts = 100
+
+// Validate tells what prevents config from building levels
+func (config GeneratorConfig) Validate() error {
+	switch {
+	case config.MinRoomSize <= 0:
+		return fmt.Errorf("generator: MinRoomSize %d must be positive", config.MinRoomSize)
+	case config.MinRoomSize > config.MaxRoomSize:
+		return fmt.Errorf("generator: MinRoomSize %d greater than MaxRoomSize %d", config.MinRoomSize, config.MaxRoomSize)
+	case config.Width < config.MinRoomSize+2 || config.Height < config.MinRoomSize+2:
+		return fmt.Errorf("generator: a %dx%d level can't hold a room of %d with its walls", config.Width, config.Height, config.MinRoomSize)
+	case config.MaxRooms < 2:
+		return fmt.Errorf("generator: MaxRooms %d must be at least 2 for the stairs", config.MaxRooms)
+	case config.RoomDensity <= 0 || config.RoomDensity > 1:
+		return fmt.Errorf("generator: RoomDensity %v must be above 0 and at most 1", config.RoomDensity)
+	case config.DoorChance < 0 || config.DoorChance > 1:
+		return fmt.Errorf("generator: DoorChance %v must be between 0 and 1", config.DoorChance)
+	}
+	return nil
+}
+
+type room struct {
+	x, y, w, h int
+}
+
+func (r room) center() Pos {
+	return Pos{r.x + r.w/2, r.y + r.h/2}
+}
+
+// intersects also checks the walls around the rooms so they never share one
+func (r room) intersects(other room) bool {
+	return r.x-1 <= other.x+other.w && r.x+r.w >= other.x-1 && r.y-1 <= other.y+other.h && r.y+r.h >= other.y-1
+}
+
+func (r room) inside(p Pos) bool {
+	return p.X >= r.x && p.X < r.x+r.w && p.Y >= r.y && p.Y < r.y+r.h
+}
+
+func (r room) onWall(p Pos) bool {
+	return p.X >= r.x-1 && p.X <= r.x+r.w && p.Y >= r.y-1 && p.Y <= r.y+r.h && !r.inside(p)
+}
+
+// GenerateLevel builds a new level made of rooms linked by corridors, with an up stair in the first room
+// and a down stair in the last one, then populates it like the authored levels with the monsters of depth
+func (game *Game) GenerateLevel(config GeneratorConfig, depth int) (*Level, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if err := game.loadDefs(); err != nil {
+		return nil, err
+	}
+	player, err := game.player()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < maxGeneratorAttempts; i++ {
+		level, ok := game.generateLevel(config, player)
+		if !ok {
+			continue
+		}
+		level.Depth = depth
+
+		// stairs are kept free of monsters and chests so the player can always arrive and leave
+		stairs := make([]Pos, 0, 2)
+		for y, row := range level.Map {
+			for x, tile := range row {
+				if tile.OverlayRune == UpStair || tile.OverlayRune == DownStair {
+					stairs = append(stairs, Pos{x, y})
+					level.Map[y][x].Walkable = false
+				}
+			}
+		}
+		game.randomizeLevel(level)
+		for _, pos := range stairs {
+			level.Map[pos.Y][pos.X].Walkable = true
+		}
+		return level, nil
+	}
+	return nil, fmt.Errorf("generator: no level built in %d attempts with %+v", maxGeneratorAttempts, config)
+}
+
+func (game *Game) generateLevel(config GeneratorConfig, player *Player) (*Level, bool) {
+	r := game.rand
+	level := game.newLevel(player, config.Width, config.Height)
+
+	rooms := make([]room, 0, config.MaxRooms)
+	covered := 0
+	target := int(float64(config.Width*config.Height) * config.RoomDensity)
+	for attempt := 0; attempt < config.MaxRooms*4 && len(rooms) < config.MaxRooms && covered < target; attempt++ {
+		w := config.MinRoomSize + r.Intn(config.MaxRoomSize-config.MinRoomSize+1)
+		h := config.MinRoomSize + r.Intn(config.MaxRoomSize-config.MinRoomSize+1)
+		if w > config.Width-2 || h > config.Height-2 {
+			continue
+		}
+		newRoom := room{x: 1 + r.Intn(config.Width-w-1), y: 1 + r.Intn(config.Height-h-1), w: w, h: h}
+
+		free := true
+		for _, other := range rooms {
+			if newRoom.intersects(other) {
+				free = false
+				break
+			}
+		}
+		if !free {
+			continue
+		}
+
+		for y := newRoom.y; y < newRoom.y+newRoom.h; y++ {
+			for x := newRoom.x; x < newRoom.x+newRoom.w; x++ {
+				carveFloor(level, Pos{x, y})
+			}
+		}
+		rooms = append(rooms, newRoom)
+		covered += w * h
+	}
+	if len(rooms) < 2 {
+		return nil, false
+	}
+
+	for i := 1; i < len(rooms); i++ {
+		carveCorridor(r, level, rooms[i-1].center(), rooms[i].center())
+	}
+
+	for y, row := range level.Map {
+		for x, tile := range row {
+			if tile.Rune == Blank && nextToFloor(level, Pos{x, y}) {
+				level.Map[y][x].Rune = StoneWall
+			}
+		}
+	}
+
+	up := rooms[0].center()
+	down := rooms[len(rooms)-1].center()
+	level.Map[up.Y][up.X].OverlayRune = UpStair
+	level.Map[down.Y][down.X].OverlayRune = DownStair
+
+	// doors are added once every room is known to be reachable, as closed ones block astar
+	for _, rm := range rooms {
+		if level.astar(up, rm.center()) == nil {
+			return nil, false
+		}
+	}
+
+	for _, rm := range rooms {
+		for y := rm.y - 1; y <= rm.y+rm.h; y++ {
+			for x := rm.x - 1; x <= rm.x+rm.w; x++ {
+				pos := Pos{x, y}
+				if rm.onWall(pos) && level.Map[y][x].Rune == DirtFloor && isDoorway(level, pos) && r.Float64() < config.DoorChance {
+					level.Map[y][x].OverlayRune = ClosedDoor
+					level.Map[y][x].Walkable = false
+					level.Map[y][x].Actionable = true
+				}
+			}
+		}
+	}
+
+	return level, true
+}
+
+func carveFloor(level *Level, pos Pos) {
+	level.Map[pos.Y][pos.X].Rune = DirtFloor
+	level.Map[pos.Y][pos.X].Walkable = true
+}
+
+// carveCorridor digs an L shaped corridor, randomly starting horizontally or vertically
+func carveCorridor(r *rand.Rand, level *Level, from, to Pos) {
+	corner := Pos{to.X, from.Y}
+	if r.Intn(2) == 0 {
+		corner = Pos{from.X, to.Y}
+	}
+	for _, segment := range [][2]Pos{{from, corner}, {corner, to}} {
+		start, end := segment[0], segment[1]
+		for p := start; ; {
+			carveFloor(level, p)
+			if p == end {
+				break
+			}
+			p.X += sign(end.X - p.X)
+			p.Y += sign(end.Y - p.Y)
+		}
+	}
+}
+
+func nextToFloor(level *Level, pos Pos) bool {
+	for y := pos.Y - 1; y <= pos.Y+1; y++ {
+		for x := pos.X - 1; x <= pos.X+1; x++ {
+			p := Pos{x, y}
+			if p != pos && inRange(level, p) && level.Map[y][x].Rune == DirtFloor {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isDoorway tells if pos is a one tile wide passage between two walls
+func isDoorway(level *Level, pos Pos) bool {
+	isWall := func(p Pos) bool {
+		return inRange(level, p) && level.Map[p.Y][p.X].Rune == StoneWall
+	}
+	return (isWall(Pos{pos.X - 1, pos.Y}) && isWall(Pos{pos.X + 1, pos.Y})) ||
+		(isWall(Pos{pos.X, pos.Y - 1}) && isWall(Pos{pos.X, pos.Y + 1}))
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	}
+	return 0
+}
+
+// descend generates the level below from and links both levels with their stairs
+func (game *Game) descend(from *Level, stair Pos) (*LevelPos, error) {
+	level, err := game.GenerateLevel(game.Generator, from.Depth+1)
+	if err != nil {
+		return nil, err
+	}
+
+	var up Pos
+	for y, row := range level.Map {
+		for x, tile := range row {
+			if tile.OverlayRune == UpStair {
+				up = Pos{x, y}
+			}
+		}
+	}
+
+	level.Name = fmt.Sprintf("depth%d-%d", level.Depth, len(game.Levels))
+	game.Levels[level.Name] = level
+	from.Portals[stair] = &LevelPos{Level: level, Pos: up}
+	level.Portals[up] = &LevelPos{Level: from, Pos: stair}
+	return from.Portals[stair], nil
+}
+
+// player returns the player of the generated levels, a new one when the game has no level yet
+func (game *Game) player() (*Player, error) {
+	if game.CurrentLevel != nil {
+		return game.CurrentLevel.Player, nil
+	}
+	return game.newPlayer()
+}
@@ -1,8 +1,7 @@
 package game
 
 import (
-	"crypto/rand"
-	"math/big"
+	"math/rand"
 )
 
 type Location int
@@ -16,6 +15,16 @@ const (
 	Legs
 )
 
+// locations are the names of the equipment slots in defs/items.csv
+var locations = map[string]Location{
+	"foots":      Foots,
+	"left_hand":  LeftHand,
+	"right_hand": RightHand,
+	"head":       Head,
+	"chest":      Chest,
+	"legs":       Legs,
+}
+
 type ItemType int
 type Rarity int
 
@@ -24,6 +33,8 @@ const (
 	Weapons
 	Potions
 	TreasureChests
+	Ammunition
+	Foods
 )
 
 const (
@@ -99,20 +110,19 @@ func adaptStatsToRarity(rarity Rarity, stats *EquipableItemStats) *EquipableItem
 	return stats
 }
 
-func randomizeRarity() Rarity {
-	number, err := rand.Int(rand.Reader, big.NewInt(100))
-	CheckError(err)
+func randomizeRarity(r *rand.Rand) Rarity {
+	number := r.Intn(100)
 
 	switch {
-	case number.Int64() <= 2:
+	case number <= 2:
 		return Legendary
-	case number.Int64() > 2 && number.Int64() <= 10:
+	case number > 2 && number <= 10:
 		return Epic
-	case number.Int64() > 10 && number.Int64() <= 20:
+	case number > 10 && number <= 20:
 		return Rare
-	case number.Int64() > 20 && number.Int64() <= 40:
+	case number > 20 && number <= 40:
 		return Uncommon
-	case number.Int64() > 40 && number.Int64() <= 100:
+	case number > 40 && number <= 100:
 		return Common
 	}
 
@@ -121,22 +131,22 @@ func randomizeRarity() Rarity {
 
 func (game *Game) adaptPlayerStats(item EquipableItem, addOrRemove string) {
 	if addOrRemove == "add" {
-		game.CurrentLevel.Player.MinDamage += item.GetStats().MinDamage
-		game.CurrentLevel.Player.MaxDamage += item.GetStats().MaxDamage
-		game.CurrentLevel.Player.Critical += item.GetStats().Critical
-		game.CurrentLevel.Player.Armor += item.GetStats().Armor
+		game.CurrentLevel.Player.addStats(item.GetStats(), 1)
 	} else if addOrRemove == "remove" {
-		game.CurrentLevel.Player.MinDamage -= item.GetStats().MinDamage
-		game.CurrentLevel.Player.MaxDamage -= item.GetStats().MaxDamage
-		game.CurrentLevel.Player.Critical -= item.GetStats().Critical
-		game.CurrentLevel.Player.Armor -= item.GetStats().Armor
+		game.CurrentLevel.Player.addStats(item.GetStats(), -1)
 	}
 }
 
 func (game *Game) equip(itemToEquip EquipableItem) {
+	player := game.CurrentLevel.Player
+	if !player.CanEquip(itemToEquip) {
+		game.CurrentLevel.AddMessage(Loot, Warning, player.Class.Name+" can't use "+itemToEquip.GetName())
+		return
+	}
 	if game.slotFreeToEquip(itemToEquip) {
 		itemToEquip.Equip()
 		game.adaptPlayerStats(itemToEquip, "add")
+		player.ActionPoints -= equipCost
 		game.CurrentLevel.Player.EquippedItems = append(game.CurrentLevel.Player.EquippedItems, itemToEquip)
 		for i, item := range game.CurrentLevel.Player.Items {
 			if item == itemToEquip {
@@ -149,6 +159,7 @@ func (game *Game) equip(itemToEquip EquipableItem) {
 func (game *Game) unEquip(itemToUnEquip EquipableItem) {
 	itemToUnEquip.UnEquip()
 	game.adaptPlayerStats(itemToUnEquip, "remove")
+	game.CurrentLevel.Player.ActionPoints -= equipCost
 	game.CurrentLevel.Player.Items = append(game.CurrentLevel.Player.Items, itemToUnEquip)
 	for i, item := range game.CurrentLevel.Player.EquippedItems {
 		if item == itemToUnEquip {
@@ -166,28 +177,45 @@ func (game *Game) slotFreeToEquip(itemToCheck EquipableItem) bool {
 	return true
 }
 
-func randomLoot(p Pos, numItems int) []Item {
-	items := make([]Item, 0)
-
-	for i := 0; i < numItems; i++ {
-		number, err := rand.Int(rand.Reader, big.NewInt(6))
-		CheckError(err)
-
-		switch {
-		case number.Int64() == 0:
-			items = append(items, NewHelmet(p))
-		case number.Int64() == 1:
-			items = append(items, NewSword(p))
-		case number.Int64() == 2:
-			items = append(items, NewPlate(p))
-		case number.Int64() == 3:
-			items = append(items, NewHealthPotion(p, "Small"))
-		case number.Int64() == 4:
-			items = append(items, NewBoots(p))
-		case number.Int64() == 5:
-			items = append(items, NewBow(p))
-		}
-	}
+// ItemDef is a base item read from defs/items.csv
+type ItemDef struct {
+	Name string
+	Type ItemType
+	// Glyph is the key of the item sprite in atlas-index-items.txt
+	Glyph    rune
+	Location Location
+	Stats    EquipableItemStats
+	// Range is how far a weapon fires, 0 for melee weapons
+	Range int
+	// Ammo is the name of the ammunition item a ranged weapon uses, none when empty
+	Ammo string
+	// Count is the number of projectiles in an ammunition item
+	Count int
+	Size  string
+	// Effect is the status effect a potion applies when consumed, none when empty
+	Effect string
+	// Nutrition is the satiation restored by a food
+	Nutrition   int
+	Description string
+}
 
-	return items
+// NewItem creates an item of the catalog at p, weapons and armors get a random rarity
+func NewItem(r *rand.Rand, def *ItemDef, p Pos) Item {
+	entity := Entity{Pos: p, Name: def.Name, Rune: def.Glyph, Type: def.Type, Description: def.Description}
+
+	switch def.Type {
+	case Weapons:
+		rarity := randomizeRarity(r)
+		stats := def.Stats
+		return &Weapon{Entity: entity, Location: def.Location, Rarity: rarity, EquipableItemStats: *adaptStatsToRarity(rarity, &stats), Range: def.Range, Ammo: def.Ammo}
+	case Armors:
+		rarity := randomizeRarity(r)
+		stats := def.Stats
+		return &Armor{Entity: entity, Location: def.Location, Rarity: rarity, EquipableItemStats: *adaptStatsToRarity(rarity, &stats)}
+	case Ammunition:
+		return &Ammo{Entity: entity, Count: def.Count}
+	case Foods:
+		return &Food{Entity: entity, Size: def.Size, Nutrition: def.Nutrition}
+	}
+	return &Potion{Entity: entity, Size: def.Size, Effect: def.Effect}
 }
@@ -0,0 +1,70 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// maxChestSize is the biggest chest randomChest can place, each size has its own chest<size> loot table
+const maxChestSize = 8
+
+// LootTable is a named weighted list of drops read from defs/loot.csv
+type LootTable struct {
+	Name    string
+	entries []lootEntry
+	total   int
+}
+
+// lootEntry drops count items, or count rolls of another table, nothing when both are nil
+type lootEntry struct {
+	item   *ItemDef
+	table  *LootTable
+	weight int
+	count  int
+}
+
+func chestLoot(size int) string {
+	return fmt.Sprintf("chest%d", size)
+}
+
+// Roll picks one entry of the table by weight and returns the items it drops at p
+func (t *LootTable) Roll(r *rand.Rand, p Pos) []Item {
+	items := make([]Item, 0)
+	if t.total == 0 {
+		return items
+	}
+
+	n := r.Intn(t.total)
+	for _, entry := range t.entries {
+		n -= entry.weight
+		if n >= 0 {
+			continue
+		}
+		for i := 0; i < entry.count; i++ {
+			switch {
+			case entry.item != nil:
+				items = append(items, NewItem(r, entry.item, p))
+			case entry.table != nil:
+				items = append(items, entry.table.Roll(r, p)...)
+			}
+		}
+		break
+	}
+	return items
+}
+
+// loops tells if rolling the table could end up rolling it again
+func (t *LootTable) loops(visiting []*LootTable) bool {
+	for _, v := range visiting {
+		if v == t {
+			return true
+		}
+	}
+	visiting = append(visiting, t)
+	for _, entry := range t.entries {
+		if entry.table != nil && entry.table.loops(visiting) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+package game
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+)
+
+// CheckMaps loads every level and the world file from the maps directory of data and reports all the problems found:
+// invalid characters, bad portals, floor unreachable from where the player enters a level
+// and player start conflicts
+func CheckMaps(data fs.FS) LoadErrors {
+	game := NewGame(0, WithData(data))
+	var problems LoadErrors
+
+	// monsters are placed on the maps by their glyph
+	if err := game.loadDefs(); err != nil {
+		if defErrs, ok := err.(LoadErrors); ok {
+			return append(problems, defErrs...)
+		}
+		return append(problems, err)
+	}
+
+	fileNames, err := fs.Glob(data, path.Join(mapDir, "*.map"))
+	if err != nil {
+		return append(problems, err)
+	}
+	if len(fileNames) == 0 {
+		return append(problems, fmt.Errorf("%s: no map found", mapDir))
+	}
+
+	levels := make(map[string]*Level, len(fileNames))
+	files := make(map[*Level]string, len(fileNames))
+	starts := make(map[*Level][]Pos, len(fileNames))
+	for _, fileName := range fileNames {
+		levelName := path.Base(fileName[:len(fileName)-len(path.Ext(fileName))])
+
+		// every level gets its own player so start positions don't overwrite each other
+		level, levelStarts, err := game.parseLevel(fileName, NewPlayer(game.rand, game.defs.Classes[0]))
+		if err != nil {
+			if mapErrs, ok := err.(LoadErrors); ok {
+				problems = append(problems, mapErrs...)
+			} else {
+				problems = append(problems, err)
+			}
+		}
+		if level == nil {
+			continue
+		}
+		levels[levelName] = level
+		files[level] = fileName
+		starts[level] = levelStarts
+	}
+
+	start, err := game.loadWorld(levels)
+	if err != nil {
+		if worldErrs, ok := err.(LoadErrors); ok {
+			problems = append(problems, worldErrs...)
+		} else {
+			problems = append(problems, err)
+		}
+	}
+
+	problems = append(problems, checkPlayerStarts(levels, files, starts, start)...)
+	problems = append(problems, checkPortals(levels, files)...)
+
+	// entry points of a level are the player starts and the portals leading to it
+	entries := make(map[*Level][]Pos, len(levels))
+	for level, levelStarts := range starts {
+		entries[level] = append(entries[level], levelStarts...)
+	}
+	for _, level := range sortedLevels(levels) {
+		for _, portal := range sortedPortals(level) {
+			to := level.Portals[portal].Level
+			entries[to] = append(entries[to], level.Portals[portal].Pos)
+		}
+	}
+
+	for _, level := range sortedLevels(levels) {
+		problems = append(problems, checkReachability(level, files[level], entries[level])...)
+	}
+
+	return problems
+}
+
+func checkPlayerStarts(levels map[string]*Level, files map[*Level]string, starts map[*Level][]Pos, start *Level) LoadErrors {
+	var problems LoadErrors
+	count := 0
+	for _, level := range sortedLevels(levels) {
+		for _, pos := range starts[level] {
+			count++
+			if count > 1 {
+				problems = append(problems, &LevelError{File: files[level], Pos: pos, Reason: "player start '@' already defined, only one is allowed across all levels"})
+			}
+			if start != nil && level != start {
+				problems = append(problems, &LevelError{File: files[level], Pos: pos, Reason: "player start '@' is not in the starting level of the world file"})
+			}
+		}
+	}
+	if count == 0 {
+		problems = append(problems, fmt.Errorf("%s: no player start '@' in any level", mapDir))
+	}
+	return problems
+}
+
+func checkPortals(levels map[string]*Level, files map[*Level]string) LoadErrors {
+	var problems LoadErrors
+	for _, level := range sortedLevels(levels) {
+		for _, pos := range sortedPortals(level) {
+			portal := level.Portals[pos]
+			if !level.Map[pos.Y][pos.X].Walkable {
+				problems = append(problems, &LevelError{File: files[level], Pos: pos, Reason: "portal is not on a walkable tile"})
+			}
+			if inRange(portal.Level, portal.Pos) && !portal.Level.Map[portal.Pos.Y][portal.Pos.X].Walkable {
+				problems = append(problems, &LevelError{File: files[portal.Level], Pos: portal.Pos, Reason: "portal destination is not a walkable tile"})
+			}
+			back := portal.Level.Portals[portal.Pos]
+			if back == nil || back.Level != level || back.Pos != pos {
+				problems = append(problems, &LevelError{File: files[level], Pos: pos, Reason: fmt.Sprintf("portal to %s %d,%d has no portal leading back", path.Base(files[portal.Level]), portal.Pos.X, portal.Pos.Y)})
+			}
+		}
+	}
+	return problems
+}
+
+// checkReachability opens every door and chest of the level, then looks for the walkable tiles and portals
+// that can't be reached from any of the level entries
+func checkReachability(level *Level, fileName string, entries []Pos) LoadErrors {
+	var problems LoadErrors
+	if len(entries) == 0 {
+		return append(problems, &LevelError{File: fileName, Reason: "level has no player start nor portal leading to it"})
+	}
+
+	level.Monsters = make(map[Pos]*Monster, 0)
+	for y, row := range level.Map {
+		for x, tile := range row {
+			if tile.Actionable {
+				level.Map[y][x].Walkable = true
+			}
+		}
+	}
+
+	for _, pos := range sortedPortals(level) {
+		if level.astar(entries[0], pos) == nil {
+			problems = append(problems, &LevelError{File: fileName, Pos: pos, Reason: "portal can't be reached from the level entry"})
+		}
+	}
+
+	reached := floodFill(level, entries)
+	for y, row := range level.Map {
+		for x, tile := range row {
+			pos := Pos{x, y}
+			if !tile.Walkable || reached[pos] {
+				continue
+			}
+			region := floodFill(level, []Pos{pos})
+			for p := range region {
+				reached[p] = true
+			}
+			problems = append(problems, &LevelError{File: fileName, Pos: pos, Reason: fmt.Sprintf("region of %d walkable tiles can't be reached from the level entry", len(region))})
+		}
+	}
+	return problems
+}
+
+func floodFill(level *Level, starts []Pos) map[Pos]bool {
+	frontier := make([]Pos, 0, 8)
+	visited := make(map[Pos]bool)
+	for _, start := range starts {
+		if canWalk(level, start) && !visited[start] {
+			frontier = append(frontier, start)
+			visited[start] = true
+		}
+	}
+
+	for len(frontier) > 0 {
+		current := frontier[0]
+		frontier = frontier[1:]
+		for _, next := range getNeighbors(level, current) {
+			if !visited[next] {
+				frontier = append(frontier, next)
+				visited[next] = true
+			}
+		}
+	}
+	return visited
+}
+
+func sortedLevels(levels map[string]*Level) []*Level {
+	names := make([]string, 0, len(levels))
+	for name := range levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sorted := make([]*Level, 0, len(levels))
+	for _, name := range names {
+		sorted = append(sorted, levels[name])
+	}
+	return sorted
+}
+
+func sortedPortals(level *Level) []Pos {
+	portals := make([]Pos, 0, len(level.Portals))
+	for pos := range level.Portals {
+		portals = append(portals, pos)
+	}
+	sort.Slice(portals, func(i, j int) bool {
+		if portals[i].Y != portals[j].Y {
+			return portals[i].Y < portals[j].Y
+		}
+		return portals[i].X < portals[j].X
+	})
+	return portals
+}
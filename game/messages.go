@@ -0,0 +1,195 @@
+package game
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Severity tells how much a message matters to the player
+type Severity int
+
+const (
+	Info Severity = iota
+	Good
+	Warning
+	Danger
+)
+
+// Category groups the messages by what they are about
+type Category int
+
+const (
+	General Category = iota
+	Combat
+	Loot
+	Magic
+	Status
+)
+
+var severityNames = map[Severity]string{Info: "info", Good: "good", Warning: "warning", Danger: "danger"}
+
+var categoryNames = map[Category]string{General: "general", Combat: "combat", Loot: "loot", Magic: "magic", Status: "status"}
+
+func (severity Severity) String() string {
+	return severityNames[severity]
+}
+
+func (category Category) String() string {
+	return categoryNames[category]
+}
+
+// Color is a hint for the viewers on how to draw a message
+type Color struct {
+	R, G, B uint8
+}
+
+var severityColors = map[Severity]Color{
+	Good:    {30, 120, 30},
+	Warning: {190, 110, 0},
+	Danger:  {180, 0, 0},
+}
+
+var categoryColors = map[Category]Color{
+	General: {100, 50, 0},
+	Combat:  {120, 40, 40},
+	Loot:    {130, 90, 0},
+	Magic:   {70, 40, 150},
+	Status:  {40, 80, 110},
+}
+
+// Message is an entry of the message log, Pos is where it happened so viewers can keep to what a hero sees
+type Message struct {
+	Turn     int      `json:"turn"`
+	Text     string   `json:"text"`
+	Severity Severity `json:"severity"`
+	Category Category `json:"category"`
+	Pos      Pos      `json:"pos"`
+}
+
+// Color returns the colour of the severity of the message, the one of its category for simple information
+func (message Message) Color() Color {
+	if color, exists := severityColors[message.Severity]; exists {
+		return color
+	}
+	return categoryColors[message.Category]
+}
+
+// MessageLog keeps every message of a run, it is shared by all the levels of a game.
+// Messages are only ever appended so a copy of the slice stays valid while the game goes on
+type MessageLog struct {
+	Messages []Message
+	// turn is the game turn new messages are stamped with, run counts the runs of the game
+	turn int
+	run  int
+}
+
+func (log *MessageLog) add(message Message) {
+	message.Turn = log.turn
+	log.Messages = append(log.Messages, message)
+}
+
+// Last returns the n most recent messages, oldest first
+func (log *MessageLog) Last(n int) []Message {
+	if n > len(log.Messages) {
+		n = len(log.Messages)
+	}
+	return log.Messages[len(log.Messages)-n:]
+}
+
+// Turn returns the game turn the last messages were written in
+func (log *MessageLog) Turn() int {
+	return log.turn
+}
+
+// Run tells the runs of a game apart, every new run starts a new log
+func (log *MessageLog) Run() int {
+	return log.run
+}
+
+// nextRun is the run of the log following log
+func (log *MessageLog) nextRun() int {
+	if log == nil {
+		return 0
+	}
+	return log.run + 1
+}
+
+// snapshot returns a log holding the messages written so far, it shares their array with log as they are never changed
+func (log *MessageLog) snapshot() *MessageLog {
+	n := len(log.Messages)
+	return &MessageLog{Messages: log.Messages[:n:n], turn: log.turn, run: log.run}
+}
+
+// Export writes the log to w as text, one message per line
+func (log *MessageLog) Export(w io.Writer) error {
+	for _, message := range log.Messages {
+		if _, err := fmt.Fprintf(w, "%5d  %-7s  %-7s  %s\n", message.Turn, message.Severity, message.Category, message.Text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportToFile appends the log to the file named fileName, the runs are separated by an empty line
+func (log *MessageLog) exportToFile(fileName string) error {
+	file, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if err = log.Export(file); err != nil {
+		file.Close()
+		return err
+	}
+	if _, err = fmt.Fprintln(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// AddMessage adds a message about the player to the log of the game
+func (level *Level) AddMessage(category Category, severity Severity, text string) {
+	var pos Pos
+	if level.Player != nil {
+		pos = level.Player.Pos
+	}
+	level.addMessageAt(pos, category, severity, text)
+}
+
+// addMessageAt adds a message about something happening on pos to the log of the game
+func (level *Level) addMessageAt(pos Pos, category Category, severity Severity, text string) {
+	level.Log.add(Message{Text: text, Severity: severity, Category: category, Pos: pos})
+}
+
+// AddEvent adds a general information to the log of the game
+func (level *Level) AddEvent(event string) {
+	level.AddMessage(General, Info, event)
+}
+
+// harmSeverity is the severity of c being hurt, only dangerous when c is a hero
+func (level *Level) harmSeverity(c *Character) Severity {
+	for _, hero := range level.Players {
+		if c == &hero.Character {
+			return Danger
+		}
+	}
+	return Info
+}
+
+// WithLogFile makes the game append its message log to the file named fileName at the end of every run
+func WithLogFile(fileName string) Option {
+	return func(game *Game) {
+		game.LogFile = fileName
+	}
+}
+
+// exportLog appends the message log of the run to the log file, if the game has one.
+// A failed export is reported to the viewers, the game goes on without it
+func (game *Game) exportLog() {
+	if game.LogFile != "" && game.Log != nil && len(game.Log.Messages) > 0 {
+		if err := game.Log.exportToFile(game.LogFile); err != nil {
+			game.reportError(fmt.Errorf("could not export the message log: %w", err))
+		}
+	}
+}
@@ -1,82 +1,34 @@
 package game
 
 import (
-	"crypto/rand"
-	"math/big"
+	"math/rand"
 )
 
 type Monster struct {
 	Character
+	// ID tells apart the monsters of a run, they are numbered from 1 in the order they appeared
+	ID  int
+	Def *MonsterDef
+	// LastKnown is where the monster last saw the player, Tracking is false once it lost its track
+	LastKnown Pos
+	Tracking  bool
 }
 
-func randomizeLoot(p Pos) []Item {
-	numItems := 0
-
-	number, err := rand.Int(rand.Reader, big.NewInt(100))
-	CheckError(err)
-
-	switch {
-	case number.Int64() <= 1:
-		numItems = 4
-	case number.Int64() > 1 && number.Int64() <= 5:
-		numItems = 3
-	case number.Int64() > 5 && number.Int64() <= 15:
-		numItems = 2
-	case number.Int64() > 15 && number.Int64() <= 35:
-		numItems = 1
-	case number.Int64() > 35 && number.Int64() <= 100:
-		numItems = 0
-	}
-
-	return randomLoot(p, numItems)
-}
-
-func NewBat(p Pos) *Monster {
-	items := randomizeLoot(p)
-	return &Monster{Character{
-		Entity:       Entity{Pos: p, Name: "Bat", Rune: Bat},
-		Health:       50,
-		MaxHealth:    50,
-		MinDamage:    2,
-		MaxDamage:    3,
-		Critical:     0,
-		Armor:        0,
-		Speed:        2.0,
-		ActionPoints: 0.0,
-		Items:        items,
-	}}
-}
-
-func NewRat(p Pos) *Monster {
-	items := randomizeLoot(p)
-	return &Monster{Character{
-		Entity:       Entity{Pos: p, Name: "Rat", Rune: Rat},
-		Health:       50,
-		MaxHealth:    50,
-		MinDamage:    1,
-		MaxDamage:    2,
-		Critical:     0,
-		Armor:        0,
-		Speed:        2.0,
-		ActionPoints: 0.0,
-		Items:        items,
-	}}
-}
-
-func NewSpider(p Pos) *Monster {
-	items := randomizeLoot(p)
-	return &Monster{Character{
-		Entity:       Entity{Pos: p, Name: "Spider", Rune: Spider},
-		Health:       10,
-		MaxHealth:    10,
-		MinDamage:    2,
-		MaxDamage:    4,
-		Critical:     0,
-		Armor:        0,
-		Speed:        1.0,
+func NewMonster(r *rand.Rand, def *MonsterDef, p Pos) *Monster {
+	items := def.loot.Roll(r, p)
+	return &Monster{Character: Character{
+		Entity:       Entity{Pos: p, Name: def.Name, Rune: def.Glyph},
+		Health:       def.Health,
+		MaxHealth:    def.Health,
+		MinDamage:    def.MinDamage,
+		MaxDamage:    def.MaxDamage,
+		Critical:     def.Critical,
+		Armor:        def.Armor,
+		Speed:        def.Speed,
 		ActionPoints: 0.0,
+		SightRange:   def.SightRange,
 		Items:        items,
-	}}
+	}, Def: def}
 }
 
 func (m *Monster) Kill(level *Level) {
@@ -89,42 +41,43 @@ func (m *Monster) Kill(level *Level) {
 	level.Items[m.Pos] = groundItems
 }
 
+// Update plays one action of the monster with its behaviour, the scheduler calls it while the monster has the energy to act
 func (m *Monster) Update(game *Game) {
-	m.ActionPoints += m.Speed
-	playerPos := game.CurrentLevel.Player.Pos
-	apInt := int(m.ActionPoints)
-	positions := game.CurrentLevel.astar(m.Pos, playerPos)
-
-	if len(positions) == 0 {
+	if m.Effect("stun") != nil {
 		m.Pass()
 		return
 	}
-
-	moveIndex := 1
-	for i := 0; i < apInt; i++ {
-		if moveIndex < len(positions) {
-			m.Move(positions[moveIndex], game)
-
-			moveIndex++
-			m.ActionPoints--
-		}
+	level := game.CurrentLevel
+	m.look(level)
+	if !behaviours[m.Def.AI].act(game, m) {
+		m.Pass()
 	}
 }
 
 func (m *Monster) Move(to Pos, game *Game) {
 	_, exists := game.CurrentLevel.Monsters[to]
-	if !exists && to != game.CurrentLevel.Player.Pos {
+	hero := game.CurrentLevel.heroAt(to)
+	if !exists && hero == nil {
 		delete(game.CurrentLevel.Monsters, m.Pos)
 		game.CurrentLevel.Monsters[to] = m
 		m.Pos = to
+		m.ActionPoints -= moveCost
+		game.CurrentLevel.emit(TurnEvent{Type: Move, Actor: m.Name, ActorID: m.CharacterID(), Pos: to})
 		return
 	}
 
-	if to == game.CurrentLevel.Player.Pos {
-		game.CurrentLevel.Attack(&m.Character, &game.CurrentLevel.Player.Character)
+	if hero != nil {
+		m.attackHero(game.CurrentLevel, hero)
 		if m.Health <= 0 {
 			delete(game.CurrentLevel.Monsters, m.Pos)
 		}
 	}
 
 }
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
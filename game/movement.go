@@ -0,0 +1,82 @@
+package game
+
+// diagonals are the moves of the diagonal inputs
+var diagonals = map[InputType]Pos{
+	UpLeft:    {-1, -1},
+	UpRight:   {1, -1},
+	DownLeft:  {-1, 1},
+	DownRight: {1, 1},
+}
+
+// costs of a move in the path finding, diagonal moves cost more so paths don't zigzag
+const (
+	straightCost = 10
+	diagonalCost = 14
+)
+
+// adjacent returns the positions around pos a character can move to from pos, whatever is on them,
+// diagonals come last and only on levels allowing them
+func (level *Level) adjacent(pos Pos) []Pos {
+	positions := []Pos{{pos.X + 1, pos.Y}, {pos.X - 1, pos.Y}, {pos.X, pos.Y - 1}, {pos.X, pos.Y + 1}}
+	if !level.Diagonal {
+		return positions
+	}
+	for _, delta := range []Pos{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}} {
+		next := Pos{pos.X + delta.X, pos.Y + delta.Y}
+		if level.canCutCorner(pos, next) {
+			positions = append(positions, next)
+		}
+	}
+	return positions
+}
+
+// canCutCorner tells if a move from to to is allowed by the corner rules: a diagonal move can't go around a wall
+// nor a closed door and can't go into or out of a doorway, straight moves are always allowed
+func (level *Level) canCutCorner(from, to Pos) bool {
+	if from.X == to.X || from.Y == to.Y {
+		return true
+	}
+	for _, pos := range []Pos{from, to, {to.X, from.Y}, {from.X, to.Y}} {
+		if !inRange(level, pos) {
+			return false
+		}
+		tile := level.Map[pos.Y][pos.X]
+		if tile.OverlayRune == ClosedDoor || tile.OverlayRune == OpenDoor {
+			return false
+		}
+	}
+	return level.Map[from.Y][to.X].Walkable && level.Map[to.Y][from.X].Walkable
+}
+
+func stepCost(from, to Pos) int {
+	if from.X != to.X && from.Y != to.Y {
+		return diagonalCost
+	}
+	return straightCost
+}
+
+// heuristic estimates the cost of the path from pos to goal, octile on levels allowing diagonal moves, Manhattan otherwise
+func (level *Level) heuristic(pos, goal Pos) int {
+	dx := abs(goal.X - pos.X)
+	dy := abs(goal.Y - pos.Y)
+	if !level.Diagonal {
+		return straightCost * (dx + dy)
+	}
+	if dx < dy {
+		dx, dy = dy, dx
+	}
+	return straightCost*(dx-dy) + diagonalCost*dy
+}
+
+// distance is the number of moves between a and b on an empty level
+func (level *Level) distance(a, b Pos) int {
+	dx := abs(a.X - b.X)
+	dy := abs(a.Y - b.Y)
+	if !level.Diagonal {
+		return dx + dy
+	}
+	if dx > dy {
+		return dx
+	}
+	return dy
+}
@@ -0,0 +1,230 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+)
+
+// WithPlayers makes the game a co-op game of n heroes, played by hot-seat or by one viewer each
+func WithPlayers(n int) Option {
+	return func(game *Game) {
+		game.NumPlayers = n
+	}
+}
+
+// WithOpenSeats makes the heroes of the party wait for their turns until a viewer joins the game to play them,
+// for games played by viewers coming and going like the ones of a server
+func WithOpenSeats() Option {
+	return func(game *Game) {
+		game.openSeats = true
+	}
+}
+
+// fieldOfView is what a hero sees of a level right now and what it has seen of it before
+type fieldOfView struct {
+	Visible [][]bool
+	Seen    [][]bool
+}
+
+// view returns the field of view of the hero id on level, empty the first time the hero comes
+func (level *Level) view(id int) *fieldOfView {
+	if view, exists := level.views[id]; exists {
+		return view
+	}
+	view := &fieldOfView{Visible: make([][]bool, len(level.Map)), Seen: make([][]bool, len(level.Map))}
+	for y, row := range level.Map {
+		view.Visible[y] = make([]bool, len(row))
+		view.Seen[y] = make([]bool, len(row))
+	}
+	if level.views == nil {
+		level.views = make(map[int]*fieldOfView)
+	}
+	level.views[id] = view
+	return view
+}
+
+// inSight tells if hero sees pos
+func (level *Level) inSight(hero *Player, pos Pos) bool {
+	return inRange(level, pos) && level.view(hero.ID).Visible[pos.Y][pos.X]
+}
+
+// hero returns the hero id of the party, nil when it is dead
+func (level *Level) hero(id int) *Player {
+	for _, hero := range level.Players {
+		if hero.ID == id {
+			return hero
+		}
+	}
+	return nil
+}
+
+// heroAt returns the hero standing on pos, nil if there is none
+func (level *Level) heroAt(pos Pos) *Player {
+	for _, hero := range level.Players {
+		if hero.Pos == pos {
+			return hero
+		}
+	}
+	return nil
+}
+
+// heroesByDistance returns the heroes of the party from the closest to pos to the furthest
+func (level *Level) heroesByDistance(pos Pos) []*Player {
+	heroes := append([]*Player(nil), level.Players...)
+	sort.SliceStable(heroes, func(i, j int) bool {
+		return level.distance(pos, heroes[i].Pos) < level.distance(pos, heroes[j].Pos)
+	})
+	return heroes
+}
+
+// canAct tells if a hero played by someone has the energy to act, the world doesn't go on while no one plays
+func (level *Level) canAct() bool {
+	played := false
+	for _, hero := range level.Players {
+		if hero.Away {
+			continue
+		}
+		played = true
+		if hero.Ready() {
+			return true
+		}
+	}
+	return !played
+}
+
+// Ready tells if c has the energy to act
+func (c *Character) Ready() bool {
+	return c.ActionPoints >= actionThreshold
+}
+
+// newParty adds the companions of the player to the starting level, next to it
+func (game *Game) newParty(start *Level) error {
+	for id := 1; id < game.NumPlayers; id++ {
+		hero, err := game.newPlayer()
+		if err != nil {
+			return err
+		}
+		hero.ID = id
+		hero.Name = fmt.Sprintf("%s %d", hero.Name, id+1)
+		start.Players = append(start.Players, hero)
+	}
+	for _, hero := range start.Players {
+		hero.Away = game.openSeats && !game.joined[hero.ID]
+	}
+	start.gatherParty(start.Player.Pos)
+	return nil
+}
+
+// gatherParty places the heroes other than the one playing on the free tiles the closest to pos
+func (level *Level) gatherParty(pos Pos) {
+	for _, hero := range level.Players {
+		if hero != level.Player {
+			hero.Pos = level.freeNear(pos)
+		}
+	}
+}
+
+// freeNear returns the walkable tile without monster or hero the closest to pos, pos itself if there is none
+func (level *Level) freeNear(pos Pos) Pos {
+	frontier := []Pos{pos}
+	visited := map[Pos]bool{pos: true}
+	for len(frontier) > 0 {
+		current := frontier[0]
+		frontier = frontier[1:]
+		if current != pos && level.heroAt(current) == nil && canWalk(level, current) {
+			return current
+		}
+		for _, next := range level.adjacent(current) {
+			if !visited[next] && inRange(level, next) && level.Map[next.Y][next.X].Walkable {
+				visited[next] = true
+				frontier = append(frontier, next)
+			}
+		}
+	}
+	return pos
+}
+
+// forEachHero plays f for every hero of the party, each of them being the player while it runs
+func (game *Game) forEachHero(f func(hero *Player)) {
+	level := game.CurrentLevel
+	active := level.Player
+	for _, hero := range level.Players {
+		level.Player = hero
+		f(hero)
+	}
+	level.Player = active
+}
+
+// checkHeroes removes the dead heroes from the party, the game starts again once they all died.
+// It returns false when the whole party is dead
+func (game *Game) checkHeroes() bool {
+	level := game.CurrentLevel
+	alive := make([]*Player, 0, len(level.Players))
+	for _, hero := range level.Players {
+		if hero.Health > 0 {
+			alive = append(alive, hero)
+		} else if hero != level.Player {
+			level.emit(TurnEvent{Type: Killed, Target: hero.Name, TargetID: hero.CharacterID(), Pos: hero.Pos})
+		}
+	}
+	if len(alive) == 0 {
+		game.Dead()
+		return false
+	}
+	if level.Player.Health <= 0 {
+		level.emit(TurnEvent{Type: Killed, Target: level.Player.Name, TargetID: level.Player.CharacterID(), Pos: level.Player.Pos})
+		level.Player = alive[0]
+	}
+	level.Players = alive
+	return true
+}
+
+// Follow makes the viewer of lchan follow the hero id, its levels are then centred on that hero and only show what it sees
+func (game *Game) Follow(lchan chan *Level, id int) {
+	game.follows[lchan] = id
+}
+
+// join adds the viewer of lchan to the game, playing the hero id or only watching it when id is negative
+func (game *Game) join(lchan chan *Level, id int) {
+	game.LevelChans = append(game.LevelChans, lchan)
+	game.Follow(lchan, id)
+	if id < 0 {
+		// a spectator doesn't play
+		return
+	}
+	if game.joined == nil {
+		game.joined = make(map[int]bool)
+	}
+	game.joined[id] = true
+	if hero := game.CurrentLevel.hero(id); hero != nil {
+		hero.Away = false
+	}
+}
+
+// leave removes the viewer of lchan from the game and closes its channel,
+// its hero waits for its turns once no other viewer plays it
+func (game *Game) leave(lchan chan *Level) {
+	close(lchan)
+	for i, c := range game.LevelChans {
+		if c == lchan {
+			game.LevelChans = append(game.LevelChans[:i], game.LevelChans[i+1:]...)
+			break
+		}
+	}
+	id := game.follows[lchan]
+	delete(game.follows, lchan)
+	for _, followed := range game.follows {
+		if followed == id {
+			return
+		}
+	}
+	delete(game.joined, id)
+	if hero := game.CurrentLevel.hero(id); hero != nil && game.openSeats {
+		hero.Away = true
+	}
+}
+
+// viewerSnapshot returns the snapshot lchan has to display, the one of its hero or of the player once its hero is dead
+func (game *Game) viewerSnapshot(lchan chan *Level) *Level {
+	return game.snapshotOf(game.follows[lchan])
+}
@@ -0,0 +1,87 @@
+package game
+
+// flowField holds the distance of every tile of a level to a goal, computed once and shared by every monster heading there
+type flowField struct {
+	goal Pos
+	// dist is the path cost indexed by y then x, -1 where the goal can't be reached
+	dist [][]int
+}
+
+// flowTo returns the flow field leading to goal, a field is kept for every hero standing on a goal
+// so monsters chasing different heroes share them, they are computed again when the walkable tiles changed
+func (level *Level) flowTo(goal Pos) *flowField {
+	if field, exists := level.flows[goal]; exists {
+		return field
+	}
+
+	field := &flowField{goal: goal, dist: make([][]int, len(level.Map))}
+	for y, row := range level.Map {
+		field.dist[y] = make([]int, len(row))
+		for x := range row {
+			field.dist[y][x] = -1
+		}
+	}
+
+	// Dijkstra from the goal on the level terrain, monsters are left out as they move every turn
+	frontier := make(pqueue, 0, 8)
+	frontier = frontier.push(goal, 0)
+	field.dist[goal.Y][goal.X] = 0
+	var current Pos
+	for len(frontier) > 0 {
+		frontier, current = frontier.pop()
+		for _, next := range terrainNeighbors(level, current) {
+			newDist := field.dist[current.Y][current.X] + stepCost(current, next)
+			if field.dist[next.Y][next.X] == -1 || newDist < field.dist[next.Y][next.X] {
+				field.dist[next.Y][next.X] = newDist
+				frontier = frontier.push(next, newDist)
+			}
+		}
+	}
+
+	// the fields of the goals heroes left won't be used again
+	for pos := range level.flows {
+		if level.heroAt(pos) == nil {
+			delete(level.flows, pos)
+		}
+	}
+	if level.flows == nil {
+		level.flows = make(map[Pos]*flowField)
+	}
+	level.flows[goal] = field
+	return field
+}
+
+// invalidatePaths drops the cached flow fields, to be called whenever a tile becomes walkable or not
+// and when the party leaves the level
+func (level *Level) invalidatePaths() {
+	level.flows = nil
+}
+
+// nextStep returns the free neighbor of from the closest to goal, false when goal can't be reached
+// or every neighbor getting closer is occupied
+func (level *Level) nextStep(from, goal Pos) (Pos, bool) {
+	field := level.flowTo(goal)
+	best := from
+	bestDist := field.dist[from.Y][from.X]
+	if bestDist <= 0 {
+		return from, false
+	}
+	for _, next := range terrainNeighbors(level, from) {
+		dist := field.dist[next.Y][next.X]
+		if _, occupied := level.Monsters[next]; !occupied && dist >= 0 && dist < bestDist {
+			best = next
+			bestDist = dist
+		}
+	}
+	return best, best != from
+}
+
+func terrainNeighbors(level *Level, pos Pos) []Pos {
+	neighbors := make([]Pos, 0, 8)
+	for _, next := range level.adjacent(pos) {
+		if inRange(level, next) && level.Map[next.Y][next.X].Walkable {
+			neighbors = append(neighbors, next)
+		}
+	}
+	return neighbors
+}
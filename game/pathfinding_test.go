@@ -0,0 +1,66 @@
+package game
+
+import (
+	"path"
+	"testing"
+)
+
+// benchLevel loads level1 with a monster on every free tile the player start can be reached from, all of them heading there
+func benchLevel(b *testing.B) (level *Level, chasers []Pos, goal Pos) {
+	game := NewGame(0)
+	if err := game.loadDefs(); err != nil {
+		b.Fatal(err)
+	}
+	fileName := path.Join(mapDir, "level1.map")
+	level, starts, err := game.parseLevel(fileName, NewPlayer(game.rand, game.defs.Classes[0]))
+	if err != nil {
+		b.Fatal(err)
+	}
+	if len(starts) == 0 {
+		b.Fatalf("%s: no player start '@' to head to", fileName)
+	}
+	goal = starts[0]
+	// doors are opened as checkReachability does, every chaser can then reach the goal
+	for y, row := range level.Map {
+		for x, tile := range row {
+			if tile.Actionable {
+				level.Map[y][x].Walkable = true
+			}
+		}
+	}
+	reached := floodFill(level, []Pos{goal})
+	for y, row := range level.Map {
+		for x, tile := range row {
+			pos := Pos{x, y}
+			if _, occupied := level.Monsters[pos]; tile.Walkable && reached[pos] && !occupied && pos != goal {
+				chasers = append(chasers, pos)
+			}
+		}
+	}
+	return level, chasers, goal
+}
+
+// BenchmarkAstar is a monster turn with an astar per monster
+func BenchmarkAstar(b *testing.B) {
+	level, chasers, goal := benchLevel(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, pos := range chasers {
+			level.astar(pos, goal)
+		}
+	}
+}
+
+// BenchmarkFlowField is a monster turn with a single flow field shared by all the monsters
+func BenchmarkFlowField(b *testing.B) {
+	level, chasers, goal := benchLevel(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		level.invalidatePaths()
+		for _, pos := range chasers {
+			level.nextStep(pos, goal)
+		}
+	}
+}
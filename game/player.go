@@ -2,21 +2,19 @@ package game
 
 type Player struct {
 	Character
-}
-
-func NewPlayer() *Player {
-	player := &Player{Character: Character{
-		Entity:        Entity{Name: "Wizard", Rune: '@'},
-		Health:        20,
-		MaxHealth:     20,
-		MinDamage:     10,
-		MaxDamage:     20,
-		Armor:         0,
-		Critical:      0,
-		Speed:         1.0,
-		ActionPoints:  0,
-		SightRange:    10,
-		InventorySize: 20,
-	}}
-	return player
+	// ID is the number of the hero in the party, the inputs it plays are tagged with it.
+	// Away heroes have no one playing them, they wait for their turns
+	ID              int
+	Away            bool
+	Class           *ClassDef
+	AbilityCooldown int
+	// LightTurns is how long the light spell still extends the sight range
+	LightTurns int
+	XP         int
+	XPLevel    int
+	// XPForLevel and XPForNextLevel are the experience thresholds around XP, XPForNextLevel is 0 at the last level
+	XPForLevel     int
+	XPForNextLevel int
+	// Satiation decreases every turn of a survival game, the player starves once it is over
+	Satiation int
 }
@@ -2,7 +2,8 @@ package game
 
 type Potion struct {
 	Entity
-	Size string
+	Size   string
+	Effect string
 }
 
 func (p *Potion) GetDescription() string {
@@ -24,20 +25,12 @@ func (p *Potion) GetSize() string {
 	return p.Size
 }
 
-func NewHealthPotion(p Pos, size string) *Potion {
-	return &Potion{
-		Entity: Entity{
-			Pos:         p,
-			Name:        "Potion",
-			Rune:        'p',
-			Type:        Potions,
-			Description: "A small health potion...",
-		},
-		Size: size,
+// consume uses up a consumable of the inventory of the player, food is eaten and potions are drunk
+func (game *Game) consume(item ConsumableItem) {
+	if food, ok := item.(*Food); ok {
+		game.eat(food)
+		return
 	}
-}
-
-func (game *Game) consumePotion(item ConsumableItem) {
 	switch item.GetSize() {
 	case "Small":
 		game.heal(&game.CurrentLevel.Player.Character, int(float64(game.CurrentLevel.Player.MaxHealth)*.25))
@@ -46,7 +39,11 @@ func (game *Game) consumePotion(item ConsumableItem) {
 	case "Large":
 		game.heal(&game.CurrentLevel.Player.Character, int(float64(game.CurrentLevel.Player.MaxHealth)*.75))
 	}
+	if potion, ok := item.(*Potion); ok && potion.Effect != "" {
+		game.CurrentLevel.addEffect(&game.CurrentLevel.Player.Character, potion.Effect)
+	}
 	game.removeInventoryItem(item, &game.CurrentLevel.Player.Character)
-	game.CurrentLevel.AddEvent(game.CurrentLevel.Player.Character.Name + " consumed " + item.GetSize() + item.GetName())
-	game.CurrentLevel.LastEvent = ConsumePotion
+	game.CurrentLevel.Player.ActionPoints -= potionCost
+	game.CurrentLevel.AddMessage(Status, Good, game.CurrentLevel.Player.Character.Name+" consumed "+item.GetSize()+item.GetName())
+	game.CurrentLevel.emit(TurnEvent{Type: ConsumePotion, Actor: game.CurrentLevel.Player.Name, ActorID: game.CurrentLevel.Player.CharacterID(), Item: item.GetName(), Pos: game.CurrentLevel.Player.Pos})
 }
@@ -0,0 +1,85 @@
+package game
+
+import "fmt"
+
+// GameShot is the projectile fired during the last turn, for the ui to animate along its path
+type GameShot struct {
+	Path []Pos
+	// Anim is the directional animation of the projectile, Blank when nothing was fired
+	Anim rune
+}
+
+// rangedWeapon returns the equipped weapon able to fire, nil if there is none
+func (p *Player) rangedWeapon() *Weapon {
+	for _, item := range p.EquippedItems {
+		if weapon, ok := item.(*Weapon); ok && weapon.Range > 0 {
+			return weapon
+		}
+	}
+	return nil
+}
+
+// fire shoots the ranged weapon of the player where it is facing,
+// the projectile stops on the first tile blocking the line of sight or on the first monster hit
+func (game *Game) fire() {
+	level := game.CurrentLevel
+	p := level.Player
+	weapon := p.rangedWeapon()
+	if weapon == nil {
+		level.AddMessage(Combat, Warning, "No ranged weapon equipped")
+		return
+	}
+
+	var ammo *Ammo
+	if weapon.Ammo != "" {
+		ammo = p.ammo(weapon.Ammo)
+		if ammo == nil {
+			level.AddMessage(Combat, Warning, fmt.Sprintf("No %s left", weapon.Ammo))
+			return
+		}
+	}
+
+	front := level.FrontOf()
+	delta := Pos{sign(front.X - p.X), sign(front.Y - p.Y)}
+	var anim rune
+	switch {
+	case delta.X > 0:
+		anim = RightAnim
+	case delta.X < 0:
+		anim = LeftAnim
+	case delta.Y > 0:
+		anim = DownAnim
+	case delta.Y < 0:
+		anim = UpAnim
+	default:
+		level.AddMessage(Combat, Info, "Nowhere to aim")
+		return
+	}
+
+	if ammo != nil {
+		ammo.Count--
+		if ammo.Count == 0 {
+			game.removeInventoryItem(ammo, &p.Character)
+			level.AddMessage(Loot, Warning, fmt.Sprintf("Last of the %s", ammo.Name))
+		}
+	}
+
+	p.ActionPoints -= attackCost
+	level.LastShot = GameShot{Anim: anim}
+	end := Pos{p.X + delta.X*weapon.Range, p.Y + delta.Y*weapon.Range}
+	line := append(bresenhamLine(p.Pos, end), end)
+	for _, pos := range line[1:] {
+		if !canSeeTrough(level, pos) {
+			break
+		}
+		if monster, exists := level.Monsters[pos]; exists {
+			level.Attack(&p.Character, &monster.Character)
+			if monster.Health <= 0 {
+				game.killMonster(monster)
+			}
+			return
+		}
+		level.LastShot.Path = append(level.LastShot.Path, pos)
+	}
+	level.AddMessage(Combat, Info, p.Name+" missed")
+}
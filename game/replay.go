@@ -0,0 +1,208 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const replayVersion = 1
+
+// item locations used to reference an item in a replay file
+const (
+	InInventory = "inventory"
+	InEquipped  = "equipped"
+	OnGround    = "ground"
+	InFront     = "front"
+)
+
+// ItemRef identifies an item by where it is relative to the player when the input is played
+type ItemRef struct {
+	Where string `json:"where"`
+	Index int    `json:"index"`
+}
+
+type replayHeader struct {
+	Version  int    `json:"version"`
+	Seed     int64  `json:"seed"`
+	Class    string `json:"class,omitempty"`
+	Diagonal bool   `json:"diagonal,omitempty"`
+	Players  int    `json:"players,omitempty"`
+}
+
+type replayRecord struct {
+	Turn       int       `json:"turn"`
+	Typ        InputType `json:"type"`
+	Item       *ItemRef  `json:"item,omitempty"`
+	Difficulty int       `json:"difficulty,omitempty"`
+	Class      string    `json:"class,omitempty"`
+	Spell      int       `json:"spell,omitempty"`
+	Survival   bool      `json:"survival,omitempty"`
+	Player     int       `json:"player,omitempty"`
+}
+
+// Recorder writes every input played by a game so the session can be replayed
+type Recorder struct {
+	w             io.Writer
+	encoder       *json.Encoder
+	headerWritten bool
+}
+
+// WithRecorder records the session inputs to w
+func WithRecorder(w io.Writer) Option {
+	return func(game *Game) {
+		game.recorder = &Recorder{w: w, encoder: json.NewEncoder(w)}
+	}
+}
+
+func (recorder *Recorder) record(game *Game, input *Input) error {
+	switch input.Typ {
+	case None, CloseWindow, Follow, Join, Leave:
+		return nil
+	}
+
+	if !recorder.headerWritten {
+		if err := recorder.encoder.Encode(replayHeader{Version: replayVersion, Seed: game.Seed, Class: game.Class, Diagonal: game.Diagonal, Players: game.NumPlayers}); err != nil {
+			return err
+		}
+		recorder.headerWritten = true
+	}
+
+	record := replayRecord{Turn: game.Turn, Typ: input.Typ, Item: input.ItemRef, Difficulty: input.Difficulty, Class: input.Class, Spell: input.Spell, Survival: input.Survival, Player: input.Player}
+	if input.Typ == LoadGame {
+		// a recorded game never continues a save, which the replay wouldn't have, it starts a new one instead
+		record.Typ = Restart
+	}
+	if input.Item != nil {
+		record.Item = game.CurrentLevel.itemRef(input.Item)
+	}
+	return recorder.encoder.Encode(record)
+}
+
+// close closes the writer of the recorder when it can be closed
+func (recorder *Recorder) close() {
+	if closer, ok := recorder.w.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// Replay is a recorded session, to be played with the same seed, class, heroes and moves it was recorded with
+type Replay struct {
+	Seed     int64
+	Class    string
+	Diagonal bool
+	Players  int
+	records  []replayRecord
+}
+
+// ReadReplay reads a session written by a Recorder
+func ReadReplay(r io.Reader) (*Replay, error) {
+	decoder := json.NewDecoder(r)
+
+	var header replayHeader
+	if err := decoder.Decode(&header); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("empty replay file")
+		}
+		return nil, err
+	}
+	if header.Version != replayVersion {
+		return nil, fmt.Errorf("unsupported replay version %d", header.Version)
+	}
+
+	replay := &Replay{Seed: header.Seed, Class: header.Class, Diagonal: header.Diagonal, Players: header.Players}
+	for {
+		var record replayRecord
+		err := decoder.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			return replay, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if record.Typ == LoadGame {
+			return nil, errors.New("the replay continues a saved game, it can't be played again")
+		}
+		replay.records = append(replay.records, record)
+	}
+}
+
+// Inputs returns the recorded inputs, items are resolved by the game when each input is played
+func (replay *Replay) Inputs() []*Input {
+	inputs := make([]*Input, 0, len(replay.records))
+	for _, record := range replay.records {
+		inputs = append(inputs, &Input{Typ: record.Typ, ItemRef: record.Item, Difficulty: record.Difficulty, Class: record.Class, Spell: record.Spell, Survival: record.Survival, Player: record.Player})
+	}
+	return inputs
+}
+
+// Feed sends the recorded inputs to a running game, waiting delay between each of them
+func (replay *Replay) Feed(inputChan chan<- *Input, delay time.Duration) {
+	for _, input := range replay.Inputs() {
+		inputChan <- input
+		time.Sleep(delay)
+	}
+}
+
+// Play runs the whole replay on a headless game and returns the snapshot of the final level.
+// It fails when an input comes at another turn than the one it was recorded in, the game went another way
+func (replay *Replay) Play(game *Game) (*Level, error) {
+	level := game.CurrentLevel.Snapshot()
+	for i, input := range replay.Inputs() {
+		if input.Typ == QuitGame {
+			break
+		}
+		if turn := replay.records[i].Turn; turn != game.Turn {
+			return level, fmt.Errorf("replay: input %d recorded at turn %d is played at turn %d", i+1, turn, game.Turn)
+		}
+		level = game.Step(input)
+	}
+	return level, nil
+}
+
+// itemRef tells where item is around the player of level, nil if it is nowhere to be found
+func (level *Level) itemRef(item Item) *ItemRef {
+	for i, it := range level.Player.Items {
+		if it == item {
+			return &ItemRef{Where: InInventory, Index: i}
+		}
+	}
+	for i, it := range level.Player.EquippedItems {
+		if it == item {
+			return &ItemRef{Where: InEquipped, Index: i}
+		}
+	}
+	for i, it := range level.Items[level.Player.Pos] {
+		if it == item {
+			return &ItemRef{Where: OnGround, Index: i}
+		}
+	}
+	for i, it := range level.Items[level.FrontOf()] {
+		if it == item {
+			return &ItemRef{Where: InFront, Index: i}
+		}
+	}
+	return nil
+}
+
+func (level *Level) resolveItemRef(ref *ItemRef) Item {
+	var items []Item
+	switch ref.Where {
+	case InInventory:
+		items = level.Player.Items
+	case InEquipped:
+		for _, item := range level.Player.EquippedItems {
+			items = append(items, item)
+		}
+	case OnGround:
+		items = level.Items[level.Player.Pos]
+	case InFront:
+		items = level.Items[level.FrontOf()]
+	}
+	if ref.Index < 0 || ref.Index >= len(items) {
+		return nil
+	}
+	return items[ref.Index]
+}
@@ -0,0 +1,38 @@
+package game
+
+import (
+	"math/rand"
+)
+
+// countingSource is the source of the random numbers of a game, it counts its draws
+// so a saved game goes on with the very numbers the game would have drawn
+type countingSource struct {
+	rand.Source64
+	draws uint64
+}
+
+func newCountingSource(seed int64) *countingSource {
+	return &countingSource{Source64: rand.NewSource(seed).(rand.Source64)}
+}
+
+func (s *countingSource) Int63() int64 {
+	s.draws++
+	return s.Source64.Int63()
+}
+
+func (s *countingSource) Uint64() uint64 {
+	s.draws++
+	return s.Source64.Uint64()
+}
+
+func (s *countingSource) Seed(seed int64) {
+	s.draws = 0
+	s.Source64.Seed(seed)
+}
+
+// skip draws numbers until draws were drawn since the source was seeded, every draw moves the source by one step
+func (s *countingSource) skip(draws uint64) {
+	for s.draws < draws {
+		s.Uint64()
+	}
+}
@@ -0,0 +1,502 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+)
+
+// SaveFileName is where the game is automatically saved when a session ends
+const SaveFileName = "airpygee.sav"
+
+const saveVersion = 13
+
+// item kinds used to tag Item interface values in a save file
+const (
+	weaponKind = "weapon"
+	armorKind  = "armor"
+	potionKind = "potion"
+	ammoKind   = "ammo"
+	foodKind   = "food"
+	chestKind  = "chest"
+)
+
+// savedGame is a game in a save file, Seed and Draws tell where its random numbers were so a continued game draws the ones that followed
+type savedGame struct {
+	Version      int                   `json:"version"`
+	Seed         int64                 `json:"seed"`
+	Draws        uint64                `json:"draws"`
+	Difficulty   int                   `json:"difficulty"`
+	Survival     bool                  `json:"survival"`
+	CurrentLevel string                `json:"currentLevel"`
+	Class        string                `json:"class"`
+	Cooldown     int                   `json:"cooldown"`
+	LightTurns   int                   `json:"lightTurns"`
+	XP           int                   `json:"xp"`
+	XPLevel      int                   `json:"xpLevel"`
+	Satiation    int                   `json:"satiation"`
+	Player       savedCharacter        `json:"player"`
+	Turn         int                   `json:"turn"`
+	MonsterIDs   int                   `json:"monsterIDs"`
+	Messages     []Message             `json:"messages"`
+	Levels       map[string]savedLevel `json:"levels"`
+}
+
+type savedLevel struct {
+	Depth    int               `json:"depth"`
+	Map      [][]Tile          `json:"map"`
+	Monsters []savedMonster    `json:"monsters"`
+	Items    []savedGroundItem `json:"items"`
+	Portals  []savedPortal     `json:"portals"`
+}
+
+type savedCharacter struct {
+	Entity        Entity      `json:"entity"`
+	Health        int         `json:"health"`
+	MaxHealth     int         `json:"maxHealth"`
+	Mana          int         `json:"mana"`
+	MaxMana       int         `json:"maxMana"`
+	MinDamage     int         `json:"minDamage"`
+	MaxDamage     int         `json:"maxDamage"`
+	Armor         int         `json:"armor"`
+	Critical      float64     `json:"critical"`
+	Speed         float64     `json:"speed"`
+	ActionPoints  float64     `json:"actionPoints"`
+	SightRange    int         `json:"sightRange"`
+	EquippedItems []savedItem `json:"equippedItems"`
+	Items         []savedItem `json:"items"`
+	InventorySize int         `json:"inventorySize"`
+	Effects       []*Effect   `json:"effects"`
+}
+
+type savedMonster struct {
+	ID        int            `json:"id"`
+	Def       string         `json:"def"`
+	Character savedCharacter `json:"character"`
+	LastKnown Pos            `json:"lastKnown"`
+	Tracking  bool           `json:"tracking"`
+}
+
+type savedGroundItem struct {
+	Pos   Pos         `json:"pos"`
+	Items []savedItem `json:"items"`
+}
+
+type savedPortal struct {
+	Pos   Pos    `json:"pos"`
+	Level string `json:"level"`
+	To    Pos    `json:"to"`
+}
+
+type savedItem struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+type savedChest struct {
+	Entity Entity      `json:"entity"`
+	Size   int         `json:"size"`
+	Opened bool        `json:"opened"`
+	Items  []savedItem `json:"items"`
+}
+
+// Save writes the whole game state to w
+func (game *Game) Save(w io.Writer) error {
+	if game.CurrentLevel == nil {
+		return errors.New("no game in progress")
+	}
+	if len(game.CurrentLevel.Players) > 1 {
+		return errors.New("games of several heroes can't be saved")
+	}
+	currentName, err := game.levelName(game.CurrentLevel)
+	if err != nil {
+		return err
+	}
+
+	player, err := saveCharacter(&game.CurrentLevel.Player.Character)
+	if err != nil {
+		return err
+	}
+
+	save := savedGame{
+		Version:      saveVersion,
+		Seed:         game.Seed,
+		Draws:        game.source.draws,
+		Difficulty:   game.Difficulty,
+		Survival:     game.Survival,
+		CurrentLevel: currentName,
+		Class:        game.CurrentLevel.Player.Class.Name,
+		Cooldown:     game.CurrentLevel.Player.AbilityCooldown,
+		LightTurns:   game.CurrentLevel.Player.LightTurns,
+		XP:           game.CurrentLevel.Player.XP,
+		XPLevel:      game.CurrentLevel.Player.XPLevel,
+		Satiation:    game.CurrentLevel.Player.Satiation,
+		Player:       player,
+		Turn:         game.Turn,
+		MonsterIDs:   game.monsterIDs,
+		Messages:     game.Log.Messages,
+		Levels:       make(map[string]savedLevel, len(game.Levels)),
+	}
+
+	for name, level := range game.Levels {
+		saved, err := game.saveLevel(level)
+		if err != nil {
+			return fmt.Errorf("level %s: %w", name, err)
+		}
+		save.Levels[name] = saved
+	}
+
+	return json.NewEncoder(w).Encode(save)
+}
+
+// Load reads a game state previously written by Save, the player and monsters are linked back to their definition in defs
+func Load(r io.Reader, defs *Defs) (*Game, error) {
+	var save savedGame
+	if err := json.NewDecoder(r).Decode(&save); err != nil {
+		return nil, err
+	}
+	if save.Version != saveVersion {
+		return nil, fmt.Errorf("unsupported save version %d", save.Version)
+	}
+
+	character, err := loadCharacter(save.Player)
+	if err != nil {
+		return nil, fmt.Errorf("player: %w", err)
+	}
+	class := defs.Class(save.Class)
+	if class == nil {
+		return nil, fmt.Errorf("unknown class %q", save.Class)
+	}
+	if save.XPLevel < 1 || save.XPLevel > len(defs.Levels) {
+		return nil, fmt.Errorf("invalid player level %d", save.XPLevel)
+	}
+	player := &Player{Character: *character, Class: class, AbilityCooldown: save.Cooldown, LightTurns: save.LightTurns, XP: save.XP, Satiation: save.Satiation}
+
+	game := &Game{Levels: make(map[string]*Level, len(save.Levels)), Difficulty: save.Difficulty, Survival: save.Survival, Class: save.Class, Turn: save.Turn, monsterIDs: save.MonsterIDs, defs: defs}
+	game.Log = &MessageLog{Messages: save.Messages, turn: save.Turn}
+	game.Seed = save.Seed
+	game.source = newCountingSource(save.Seed)
+	game.source.skip(save.Draws)
+	game.rand = rand.New(game.source)
+	game.setXPLevel(player, save.XPLevel)
+
+	// levels are created first so portals can point to any of them
+	for name := range save.Levels {
+		game.Levels[name] = &Level{
+			Name:     name,
+			Player:   player,
+			Players:  []*Player{player},
+			Log:      game.Log,
+			Monsters: make(map[Pos]*Monster, 0),
+			Portals:  make(map[Pos]*LevelPos, 0),
+			Items:    make(map[Pos][]Item, 0),
+		}
+	}
+
+	for name, saved := range save.Levels {
+		level := game.Levels[name]
+		level.Map = saved.Map
+		level.Depth = saved.Depth
+		// the player sees what is visible and saw what was seen of the level
+		view := level.view(player.ID)
+		for y, row := range level.Map {
+			for x, tile := range row {
+				view.Visible[y][x] = tile.Visible
+				view.Seen[y][x] = tile.Seen
+			}
+		}
+		for _, m := range saved.Monsters {
+			def, exists := defs.monstersByName[m.Def]
+			if !exists {
+				return nil, fmt.Errorf("level %s: unknown monster %q", name, m.Def)
+			}
+			character, err := loadCharacter(m.Character)
+			if err != nil {
+				return nil, fmt.Errorf("level %s: %w", name, err)
+			}
+			level.Monsters[character.Pos] = &Monster{Character: *character, ID: m.ID, Def: def, LastKnown: m.LastKnown, Tracking: m.Tracking}
+		}
+		for _, ground := range saved.Items {
+			items, err := loadItems(ground.Items)
+			if err != nil {
+				return nil, fmt.Errorf("level %s: %w", name, err)
+			}
+			level.Items[ground.Pos] = items
+		}
+		for _, portal := range saved.Portals {
+			to, exists := game.Levels[portal.Level]
+			if !exists {
+				return nil, fmt.Errorf("level %s: portal to unknown level %s", name, portal.Level)
+			}
+			level.Portals[portal.Pos] = &LevelPos{Level: to, Pos: portal.To}
+		}
+	}
+
+	game.CurrentLevel = game.Levels[save.CurrentLevel]
+	if game.CurrentLevel == nil {
+		return nil, fmt.Errorf("unknown current level %s", save.CurrentLevel)
+	}
+	return game, nil
+}
+
+// HasSave tells if a saved game can be continued
+func HasSave() bool {
+	_, err := os.Stat(SaveFileName)
+	return err == nil
+}
+
+func (game *Game) saveToFile(fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	if err = game.Save(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (game *Game) loadFromFile(fileName string) error {
+	if game.NumPlayers > 1 {
+		return errors.New("saved games have a single hero, they can't be continued by a party")
+	}
+	if game.recorder != nil {
+		return errors.New("a recorded session starts a new game, it can't be replayed from a save")
+	}
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err = game.loadDefs(); err != nil {
+		return err
+	}
+	loaded, err := Load(file, game.defs)
+	if err != nil {
+		return err
+	}
+	game.Seed, game.source, game.rand = loaded.Seed, loaded.source, loaded.rand
+	for _, level := range loaded.Levels {
+		level.rand = game.rand
+		level.Diagonal = game.Diagonal
+		level.Survival = loaded.Survival
+	}
+	game.exportLog()
+	loaded.Log.run = game.Log.nextRun()
+	game.Levels = loaded.Levels
+	game.Log = loaded.Log
+	game.Turn, game.ticks = loaded.Turn, 0
+	game.monsterIDs = loaded.monsterIDs
+	game.CurrentLevel = loaded.CurrentLevel
+	game.Difficulty = loaded.Difficulty
+	game.Survival = loaded.Survival
+	game.Class = loaded.Class
+	return nil
+}
+
+func (game *Game) levelName(level *Level) (string, error) {
+	for name, l := range game.Levels {
+		if l == level {
+			return name, nil
+		}
+	}
+	return "", errors.New("level is not part of the game")
+}
+
+func (game *Game) saveLevel(level *Level) (savedLevel, error) {
+	saved := savedLevel{Map: level.Map, Depth: level.Depth}
+
+	for _, monster := range level.Monsters {
+		character, err := saveCharacter(&monster.Character)
+		if err != nil {
+			return saved, err
+		}
+		saved.Monsters = append(saved.Monsters, savedMonster{ID: monster.ID, Def: monster.Def.Name, Character: character, LastKnown: monster.LastKnown, Tracking: monster.Tracking})
+	}
+
+	for pos, items := range level.Items {
+		if len(items) == 0 {
+			continue
+		}
+		savedItems, err := saveItems(items)
+		if err != nil {
+			return saved, err
+		}
+		saved.Items = append(saved.Items, savedGroundItem{Pos: pos, Items: savedItems})
+	}
+
+	for pos, portal := range level.Portals {
+		name, err := game.levelName(portal.Level)
+		if err != nil {
+			return saved, err
+		}
+		saved.Portals = append(saved.Portals, savedPortal{Pos: pos, Level: name, To: portal.Pos})
+	}
+
+	return saved, nil
+}
+
+func saveCharacter(c *Character) (savedCharacter, error) {
+	saved := savedCharacter{
+		Entity:        c.Entity,
+		Health:        c.Health,
+		MaxHealth:     c.MaxHealth,
+		Mana:          c.Mana,
+		MaxMana:       c.MaxMana,
+		MinDamage:     c.MinDamage,
+		MaxDamage:     c.MaxDamage,
+		Armor:         c.Armor,
+		Critical:      c.Critical,
+		Speed:         c.Speed,
+		ActionPoints:  c.ActionPoints,
+		SightRange:    c.SightRange,
+		InventorySize: c.InventorySize,
+		Effects:       c.Effects,
+	}
+
+	var err error
+	saved.Items, err = saveItems(c.Items)
+	if err != nil {
+		return saved, err
+	}
+
+	equipped := make([]Item, 0, len(c.EquippedItems))
+	for _, item := range c.EquippedItems {
+		equipped = append(equipped, item)
+	}
+	saved.EquippedItems, err = saveItems(equipped)
+	return saved, err
+}
+
+func loadCharacter(saved savedCharacter) (*Character, error) {
+	c := &Character{
+		Entity:        saved.Entity,
+		Health:        saved.Health,
+		MaxHealth:     saved.MaxHealth,
+		Mana:          saved.Mana,
+		MaxMana:       saved.MaxMana,
+		MinDamage:     saved.MinDamage,
+		MaxDamage:     saved.MaxDamage,
+		Armor:         saved.Armor,
+		Critical:      saved.Critical,
+		Speed:         saved.Speed,
+		ActionPoints:  saved.ActionPoints,
+		SightRange:    saved.SightRange,
+		InventorySize: saved.InventorySize,
+		Effects:       saved.Effects,
+	}
+	for _, effect := range c.Effects {
+		if effects[effect.Name] == nil {
+			return nil, fmt.Errorf("unknown effect %q", effect.Name)
+		}
+	}
+
+	var err error
+	c.Items, err = loadItems(saved.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	equipped, err := loadItems(saved.EquippedItems)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range equipped {
+		equipable, ok := item.(EquipableItem)
+		if !ok {
+			return nil, fmt.Errorf("%s can't be equipped", item.GetName())
+		}
+		c.EquippedItems = append(c.EquippedItems, equipable)
+	}
+	return c, nil
+}
+
+func saveItems(items []Item) ([]savedItem, error) {
+	saved := make([]savedItem, 0, len(items))
+	for _, item := range items {
+		s, err := saveItem(item)
+		if err != nil {
+			return nil, err
+		}
+		saved = append(saved, s)
+	}
+	return saved, nil
+}
+
+func loadItems(saved []savedItem) ([]Item, error) {
+	items := make([]Item, 0, len(saved))
+	for _, s := range saved {
+		item, err := loadItem(s)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func saveItem(item Item) (savedItem, error) {
+	var kind string
+	var value interface{} = item
+
+	switch i := item.(type) {
+	case *Weapon:
+		kind = weaponKind
+	case *Armor:
+		kind = armorKind
+	case *Potion:
+		kind = potionKind
+	case *Ammo:
+		kind = ammoKind
+	case *Food:
+		kind = foodKind
+	case *TreasureChest:
+		kind = chestKind
+		items, err := saveItems(i.Items)
+		if err != nil {
+			return savedItem{}, err
+		}
+		value = savedChest{Entity: i.Entity, Size: i.Size, Opened: i.Opened, Items: items}
+	default:
+		return savedItem{}, fmt.Errorf("can't save item %s of type %T", item.GetName(), item)
+	}
+
+	data, err := json.Marshal(value)
+	return savedItem{Kind: kind, Data: data}, err
+}
+
+func loadItem(saved savedItem) (Item, error) {
+	var item Item
+	switch saved.Kind {
+	case weaponKind:
+		item = &Weapon{}
+	case armorKind:
+		item = &Armor{}
+	case potionKind:
+		item = &Potion{}
+	case ammoKind:
+		item = &Ammo{}
+	case foodKind:
+		item = &Food{}
+	case chestKind:
+		var chest savedChest
+		if err := json.Unmarshal(saved.Data, &chest); err != nil {
+			return nil, err
+		}
+		items, err := loadItems(chest.Items)
+		if err != nil {
+			return nil, err
+		}
+		return &TreasureChest{Entity: chest.Entity, Size: chest.Size, Opened: chest.Opened, Items: items}, nil
+	default:
+		return nil, fmt.Errorf("unknown item kind %q", saved.Kind)
+	}
+
+	err := json.Unmarshal(saved.Data, item)
+	return item, err
+}
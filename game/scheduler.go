@@ -0,0 +1,108 @@
+package game
+
+// actionThreshold is the energy a character needs to act, every action then costs some of it
+const actionThreshold = 100
+
+// energyPerTick is the energy gained each tick by a character of speed 1
+const energyPerTick = 10
+
+// ticksPerTurn is the number of ticks in a game turn, the time a character of speed 1 needs to move once.
+// Cooldowns, mana regeneration and status effects run once per turn
+const ticksPerTurn = actionThreshold / energyPerTick
+
+// energy costs of the actions
+const (
+	moveCost    = 100
+	attackCost  = 100
+	waitCost    = 100
+	spellCost   = 100
+	abilityCost = 100
+	doorCost    = 50
+	potionCost  = 50
+	eatCost     = 100
+	equipCost   = 50
+	pickupCost  = 50
+	dropCost    = 25
+)
+
+// energyGain is the energy c gains each tick, following its speed and its haste or slow effects
+func (c *Character) energyGain() float64 {
+	gain := c.Speed * energyPerTick
+	if c.Effect("haste") != nil {
+		gain *= 2
+	}
+	if c.Effect("slow") != nil {
+		gain /= 2
+	}
+	return gain
+}
+
+// waitForPlayers runs the world until a hero has gathered enough energy to act again,
+// or right away when no hero gains any as it would run forever
+func (game *Game) waitForPlayers() {
+	for !game.CurrentLevel.canAct() {
+		if !game.CurrentLevel.gainsEnergy() || !game.tick() {
+			return
+		}
+	}
+}
+
+// gainsEnergy tells if a hero played by someone gains energy with the ticks
+func (level *Level) gainsEnergy() bool {
+	for _, hero := range level.Players {
+		if !hero.Away && hero.energyGain() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// tick gives every character of the current level its energy, lets the monsters act while they have enough
+// and runs the turn updates every ticksPerTurn ticks. It returns false when the whole party died
+func (game *Game) tick() bool {
+	level := game.CurrentLevel
+	game.ticks++
+	for _, hero := range level.Players {
+		hero.ActionPoints += hero.energyGain()
+		if hero.Away && hero.Ready() {
+			hero.Pass()
+		}
+	}
+
+	for _, monster := range level.sortedMonsters() {
+		if level.Monsters[monster.Pos] != monster {
+			continue
+		}
+		monster.ActionPoints += monster.energyGain()
+		for monster.ActionPoints >= actionThreshold && level.Monsters[monster.Pos] == monster {
+			energy := monster.ActionPoints
+			monster.Update(game)
+			if monster.ActionPoints >= energy {
+				// an update always costs something, a monster can't keep its turn forever
+				monster.Pass()
+			}
+			if !game.checkHeroes() {
+				return false
+			}
+		}
+	}
+
+	if game.ticks%ticksPerTurn == 0 {
+		game.Turn++
+		game.Log.turn = game.Turn
+		game.forEachHero(func(hero *Player) {
+			if hero.AbilityCooldown > 0 {
+				hero.AbilityCooldown--
+			}
+			game.updateSpells()
+		})
+		game.updateEffects()
+		game.forEachHero(func(hero *Player) {
+			game.updateHunger()
+		})
+		if !game.checkHeroes() {
+			return false
+		}
+	}
+	return true
+}
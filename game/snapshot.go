@@ -0,0 +1,139 @@
+package game
+
+// Snapshot returns a copy of level sharing no mutable state with it, for the viewers to read while the game goes on.
+// Portals of a snapshot only keep their position on the level, not the level they lead to
+func (level *Level) Snapshot() *Level {
+	return level.snapshotFor(level.Player)
+}
+
+// snapshotFor returns a snapshot of level following hero, its tiles are only visible or seen when hero sees them
+func (level *Level) snapshotFor(hero *Player) *Level {
+	snapshot := &Level{
+		Name:     level.Name,
+		Map:      make([][]Tile, len(level.Map)),
+		Monsters: make(map[Pos]*Monster, len(level.Monsters)),
+		Players:  make([]*Player, 0, len(level.Players)),
+		Portals:  make(map[Pos]*LevelPos, len(level.Portals)),
+		Items:    make(map[Pos][]Item, len(level.Items)),
+		Log:      level.Log.snapshot(),
+		LastSpell: GameSpell{
+			Name:    level.LastSpell.Name,
+			Path:    append([]Pos(nil), level.LastSpell.Path...),
+			Impacts: append([]Pos(nil), level.LastSpell.Impacts...),
+		},
+		LastShot:   GameShot{Path: append([]Pos(nil), level.LastShot.Path...), Anim: level.LastShot.Anim},
+		TurnEvents: append([]TurnEvent(nil), level.TurnEvents...),
+		Depth:      level.Depth,
+		Diagonal:   level.Diagonal,
+		Survival:   level.Survival,
+	}
+	view := level.view(hero.ID)
+	for y, row := range level.Map {
+		snapshot.Map[y] = append([]Tile(nil), row...)
+		for x := range row {
+			snapshot.Map[y][x].Visible = view.Visible[y][x]
+			snapshot.Map[y][x].Seen = view.Seen[y][x]
+		}
+	}
+
+	for _, p := range level.Players {
+		player := *p
+		player.Character = cloneCharacter(&p.Character)
+		snapshot.Players = append(snapshot.Players, &player)
+		if p == hero {
+			snapshot.Player = &player
+		}
+	}
+
+	for pos, monster := range level.Monsters {
+		m := *monster
+		m.Character = cloneCharacter(&monster.Character)
+		snapshot.Monsters[pos] = &m
+	}
+	for pos, portal := range level.Portals {
+		snapshot.Portals[pos] = &LevelPos{Pos: portal.Pos}
+	}
+	for pos, items := range level.Items {
+		snapshot.Items[pos] = cloneItems(items)
+	}
+	if level.Debug != nil {
+		snapshot.Debug = make(map[Pos]bool, len(level.Debug))
+		for pos, debug := range level.Debug {
+			snapshot.Debug[pos] = debug
+		}
+	}
+	return snapshot
+}
+
+func cloneCharacter(c *Character) Character {
+	clone := *c
+	clone.Items = cloneItems(c.Items)
+	clone.EquippedItems = make([]EquipableItem, 0, len(c.EquippedItems))
+	for _, item := range c.EquippedItems {
+		clone.EquippedItems = append(clone.EquippedItems, cloneItem(item).(EquipableItem))
+	}
+	clone.Effects = make([]*Effect, 0, len(c.Effects))
+	for _, effect := range c.Effects {
+		e := *effect
+		clone.Effects = append(clone.Effects, &e)
+	}
+	return clone
+}
+
+func cloneItems(items []Item) []Item {
+	clones := make([]Item, 0, len(items))
+	for _, item := range items {
+		clones = append(clones, cloneItem(item))
+	}
+	return clones
+}
+
+func cloneItem(item Item) Item {
+	switch i := item.(type) {
+	case *Weapon:
+		clone := *i
+		return &clone
+	case *Armor:
+		clone := *i
+		return &clone
+	case *Potion:
+		clone := *i
+		return &clone
+	case *Ammo:
+		clone := *i
+		return &clone
+	case *Food:
+		clone := *i
+		return &clone
+	case *TreasureChest:
+		clone := *i
+		clone.Items = cloneItems(i.Items)
+		return &clone
+	}
+	panic("Tried to copy an unknown item")
+}
+
+// takeSnapshots takes a snapshot of the current level seen by every hero,
+// the items of the inputs of a hero are then looked for in its snapshot
+func (game *Game) takeSnapshots() {
+	level := game.CurrentLevel
+	game.snapshots = make(map[int]*Level, len(level.Players))
+	for _, hero := range level.Players {
+		game.snapshots[hero.ID] = level.snapshotFor(hero)
+	}
+}
+
+// snapshotOf returns the last snapshot of the hero id, the one of the player once its hero is dead
+func (game *Game) snapshotOf(id int) *Level {
+	if snapshot, exists := game.snapshots[id]; exists {
+		return snapshot
+	}
+	return game.snapshots[game.CurrentLevel.Player.ID]
+}
+
+// publish sends to every viewer the snapshot of the hero it follows
+func (game *Game) publish() {
+	for _, lchan := range game.LevelChans {
+		lchan <- game.viewerSnapshot(lchan)
+	}
+}
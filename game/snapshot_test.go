@@ -0,0 +1,52 @@
+package game
+
+import (
+	"testing"
+)
+
+// TestSnapshotsHeadless reads every snapshot published while the game goes on, go test -race tells if they share state with it
+func TestSnapshotsHeadless(t *testing.T) {
+	game := NewGame(1, WithSeed(1))
+	read := make(chan int)
+	go func() {
+		snapshots := 0
+		for level := range game.LevelChans[0] {
+			for _, row := range level.Map {
+				for _, tile := range row {
+					_ = tile.Rune == tile.OverlayRune && tile.Visible
+				}
+			}
+			for pos, monster := range level.Monsters {
+				_ = monster.Pos == pos && monster.Health > 0
+			}
+			for _, message := range level.Log.Last(10) {
+				_ = message.Text
+			}
+			for _, item := range level.Player.Items {
+				_ = item.GetName()
+			}
+			snapshots++
+		}
+		read <- snapshots
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		game.Run()
+		close(done)
+	}()
+	moves := []InputType{Up, Right, Down, Left, Right, Right, Down, Down, TakeAll, Action}
+	for i := 0; i < 200; i++ {
+		game.InputChan <- &Input{Typ: moves[i%len(moves)]}
+	}
+	close(game.InputChan)
+	<-done
+	close(game.LevelChans[0])
+
+	if game.Turn == 0 {
+		t.Error("the moves didn't make the game go on")
+	}
+	if snapshots := <-read; snapshots < 200 {
+		t.Errorf("read %d snapshots, want one per input at least", snapshots)
+	}
+}
@@ -0,0 +1,207 @@
+package game
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxSpells is the size of a spellbook, one spell per casting key
+const maxSpells = 4
+
+// manaRegenTurns is the number of turns the player needs to regenerate one mana point
+const manaRegenTurns = 2
+
+// lightBonus is how much the light spell extends the sight range while it lasts
+const lightBonus = 4
+
+// Spell is cast by the player for its mana cost, damaging spells ignore armor
+type Spell struct {
+	Name        string
+	Description string
+	Mana        int
+	Range       int
+	MinDamage   int
+	MaxDamage   int
+	Turns       int
+	// Effect is the status effect put on the monsters surviving the spell
+	Effect string
+	// cast returns false when the spell had no effect, the mana is then not spent
+	cast func(game *Game, spell *Spell) bool
+}
+
+// GameSpell is the spell cast during the last turn, for the ui to animate its projectile path then its impacts
+type GameSpell struct {
+	Name    string
+	Path    []Pos
+	Impacts []Pos
+}
+
+var spells = map[string]*Spell{
+	"firebolt": {
+		Name:        "Firebolt",
+		Description: "Hits the nearest monster in sight",
+		Mana:        5,
+		Range:       8,
+		MinDamage:   8,
+		MaxDamage:   14,
+		cast:        firebolt,
+	},
+	"frost_nova": {
+		Name:        "Frost nova",
+		Description: "Hits and slows every monster in sight up to 2 tiles away",
+		Mana:        8,
+		Range:       2,
+		MinDamage:   4,
+		MaxDamage:   8,
+		Effect:      "slow",
+		cast:        frostNova,
+	},
+	"mend": {
+		Name:        "Mend",
+		Description: "Restores a quarter of the health",
+		Mana:        6,
+		cast:        mend,
+	},
+	"light": {
+		Name:        "Light",
+		Description: fmt.Sprintf("Extends the sight range by %d for a while", lightBonus),
+		Mana:        4,
+		Turns:       30,
+		cast:        light,
+	},
+}
+
+func (game *Game) castSpell(index int) {
+	level := game.CurrentLevel
+	p := level.Player
+	if index < 0 || index >= len(p.Class.Spells) {
+		level.AddMessage(Magic, Info, fmt.Sprintf("No spell on key %d", index+1))
+		return
+	}
+	spell := p.Class.Spells[index]
+	if p.Mana < spell.Mana {
+		level.AddMessage(Magic, Warning, fmt.Sprintf("Not enough mana for %s", spell.Name))
+		return
+	}
+
+	level.LastSpell = GameSpell{Name: spell.Name}
+	if !spell.cast(game, spell) {
+		level.LastSpell = GameSpell{}
+		return
+	}
+	p.Mana -= spell.Mana
+	p.ActionPoints -= spellCost
+	level.emit(TurnEvent{Type: SpellCast, Actor: p.Name, ActorID: p.CharacterID(), Item: spell.Name, Pos: p.Pos})
+}
+
+// updateSpells regenerates the player mana and ends the light spell when its turns are over
+func (game *Game) updateSpells() {
+	p := game.CurrentLevel.Player
+	if p.Mana < p.MaxMana && game.Turn%manaRegenTurns == 0 {
+		p.Mana++
+	}
+	if p.LightTurns > 0 {
+		p.LightTurns--
+		if p.LightTurns == 0 {
+			p.SightRange -= lightBonus
+			game.CurrentLevel.lineOfSight()
+			game.CurrentLevel.AddMessage(Magic, Info, "The light fades")
+		}
+	}
+}
+
+func (game *Game) spellDamage(spell *Spell, monster *Monster) {
+	level := game.CurrentLevel
+	damage := randomizeDamage(level.rand, spell.MinDamage, spell.MaxDamage)
+	monster.Health -= damage
+	level.LastSpell.Impacts = append(level.LastSpell.Impacts, monster.Pos)
+	if monster.Health > 0 {
+		level.addMessageAt(monster.Pos, Magic, Info, fmt.Sprintf("%s hit %s for %d", spell.Name, monster.Name, damage))
+		if spell.Effect != "" {
+			level.addEffect(&monster.Character, spell.Effect)
+		}
+		return
+	}
+	level.addMessageAt(monster.Pos, Magic, Good, fmt.Sprintf("%s killed %s", spell.Name, monster.Name))
+	game.killMonster(monster)
+}
+
+func distance(a, b Pos) float64 {
+	x := float64(a.X - b.X)
+	y := float64(a.Y - b.Y)
+	return math.Sqrt(x*x + y*y)
+}
+
+func firebolt(game *Game, spell *Spell) bool {
+	level := game.CurrentLevel
+	p := level.Player
+
+	var target *Monster
+	for _, monster := range level.sortedMonsters() {
+		if !level.inSight(p, monster.Pos) || distance(p.Pos, monster.Pos) > float64(spell.Range) {
+			continue
+		}
+		if target == nil || distance(p.Pos, monster.Pos) < distance(p.Pos, target.Pos) {
+			target = monster
+		}
+	}
+	if target == nil {
+		level.AddMessage(Magic, Info, "No monster in sight")
+		return false
+	}
+
+	// the bolt flies along the line to the target and stops on the first wall or monster in the way
+	line := append(bresenhamLine(p.Pos, target.Pos), target.Pos)
+	for _, pos := range line[1:] {
+		if !canSeeTrough(level, pos) {
+			level.AddMessage(Magic, Info, spell.Name+" hit a wall")
+			return true
+		}
+		level.LastSpell.Path = append(level.LastSpell.Path, pos)
+		if monster, exists := level.Monsters[pos]; exists {
+			game.spellDamage(spell, monster)
+			return true
+		}
+	}
+	return true
+}
+
+func frostNova(game *Game, spell *Spell) bool {
+	level := game.CurrentLevel
+	p := level.Player
+	hit := false
+	for _, monster := range level.sortedMonsters() {
+		if !level.inSight(p, monster.Pos) || abs(monster.X-p.X) > spell.Range || abs(monster.Y-p.Y) > spell.Range {
+			continue
+		}
+		game.spellDamage(spell, monster)
+		hit = true
+	}
+	if !hit {
+		level.AddMessage(Magic, Info, "No monster around")
+	}
+	return hit
+}
+
+func mend(game *Game, spell *Spell) bool {
+	p := game.CurrentLevel.Player
+	if p.Health == p.MaxHealth {
+		game.CurrentLevel.AddMessage(Magic, Info, p.Name+" is not hurt")
+		return false
+	}
+	game.heal(&p.Character, p.MaxHealth/4+1)
+	game.CurrentLevel.AddMessage(Magic, Good, p.Name+" mended")
+	return true
+}
+
+func light(game *Game, spell *Spell) bool {
+	level := game.CurrentLevel
+	p := level.Player
+	if p.LightTurns == 0 {
+		p.SightRange += lightBonus
+	}
+	p.LightTurns = spell.Turns
+	level.lineOfSight()
+	level.AddMessage(Magic, Info, p.Name+" lights up the surroundings")
+	return true
+}
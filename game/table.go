@@ -0,0 +1,125 @@
+package game
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// table is a csv definition file whose first line names the columns, so designers can reorder them
+type table struct {
+	file    string
+	columns map[string]int
+	rows    []tableRow
+}
+
+type tableRow struct {
+	table  *table
+	line   int
+	fields []string
+	errs   *LoadErrors
+}
+
+// readTable reads fileName from data and checks every required column is present
+func readTable(data fs.FS, fileName string, required ...string) (*table, error) {
+	file, err := data.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	csvReader := csv.NewReader(file)
+	csvReader.TrimLeadingSpace = true
+	csvReader.Comment = '#'
+	csvReader.FieldsPerRecord = -1
+
+	header, err := csvReader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, &DataError{File: fileName, Line: 1, Reason: "missing header line"}
+	}
+	if err != nil {
+		return nil, &DataError{File: fileName, Line: 1, Reason: "invalid header line", Err: err}
+	}
+
+	t := &table{file: fileName, columns: make(map[string]int, len(header))}
+	for i, name := range header {
+		t.columns[strings.TrimSpace(name)] = i
+	}
+
+	var errs LoadErrors
+	for _, name := range required {
+		if _, exists := t.columns[name]; !exists {
+			errs = append(errs, &DataError{File: fileName, Line: 1, Column: name, Reason: "missing column"})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	for {
+		fields, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line, _ := csvReader.FieldPos(0)
+		if err != nil {
+			return nil, &DataError{File: fileName, Line: line, Reason: "invalid line", Err: err}
+		}
+		if len(fields) != len(header) {
+			errs = append(errs, &DataError{File: fileName, Line: line, Reason: fmt.Sprintf("%d fields instead of %d", len(fields), len(header))})
+			continue
+		}
+		t.rows = append(t.rows, tableRow{table: t, line: line, fields: fields})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return t, nil
+}
+
+// each calls fn with every row of the table and gathers the errors reported by the rows
+func (t *table) each(fn func(row *tableRow)) LoadErrors {
+	var errs LoadErrors
+	for i := range t.rows {
+		row := &t.rows[i]
+		row.errs = &errs
+		fn(row)
+	}
+	return errs
+}
+
+func (row *tableRow) fail(column, reason string, err error) {
+	*row.errs = append(*row.errs, &DataError{File: row.table.file, Line: row.line, Column: column, Reason: reason, Err: err})
+}
+
+func (row *tableRow) str(column string) string {
+	return strings.TrimSpace(row.fields[row.table.columns[column]])
+}
+
+func (row *tableRow) int(column string) int {
+	value, err := strconv.Atoi(row.str(column))
+	if err != nil {
+		row.fail(column, "invalid number", err)
+		return 0
+	}
+	if value < 0 {
+		row.fail(column, "must not be negative", nil)
+	}
+	return value
+}
+
+func (row *tableRow) float(column string) float64 {
+	value, err := strconv.ParseFloat(row.str(column), 64)
+	if err != nil {
+		row.fail(column, "invalid number", err)
+		return 0
+	}
+	if value < 0 {
+		row.fail(column, "must not be negative", nil)
+	}
+	return value
+}
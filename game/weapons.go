@@ -6,14 +6,8 @@ type Weapon struct {
 	Equipped bool
 	Location
 	Rarity
-}
-
-type Sword struct {
-	Weapon
-}
-
-type Bow struct {
-	Weapon
+	Range int
+	Ammo  string
 }
 
 func (w *Weapon) GetDescription() string {
@@ -64,47 +58,3 @@ func (w *Weapon) ToString(rarity Rarity) string {
 func (w *Weapon) GetLocation() Location {
 	return w.Location
 }
-
-func NewSword(p Pos) *Sword {
-	rarity := randomizeRarity()
-	stats := adaptStatsToRarity(rarity, &EquipableItemStats{
-		MinDamage: 5,
-		MaxDamage: 10,
-		Armor:     0,
-		Critical:  0,
-	})
-	return &Sword{
-		Weapon: Weapon{Entity: Entity{
-			Pos:         p,
-			Name:        "Sword",
-			Rune:        's',
-			Type:        Weapons,
-			Description: "A common sword...",
-		},
-			Location:           RightHand,
-			Rarity:             rarity,
-			EquipableItemStats: *stats,
-		}}
-}
-
-func NewBow(p Pos) *Bow {
-	rarity := randomizeRarity()
-	stats := adaptStatsToRarity(rarity, &EquipableItemStats{
-		MinDamage: 5,
-		MaxDamage: 10,
-		Armor:     0,
-		Critical:  0,
-	})
-	return &Bow{
-		Weapon: Weapon{Entity: Entity{
-			Pos:         p,
-			Name:        "Bow",
-			Rune:        'B',
-			Type:        Weapons,
-			Description: "A common bow...",
-		},
-			Location:           RightHand,
-			Rarity:             rarity,
-			EquipableItemStats: *stats,
-		}}
-}
@@ -3,6 +3,10 @@ package main
 import (
 	"AirPygee/game"
 	"AirPygee/ui2d"
+	"flag"
+	"io/fs"
+	"os"
+	"time"
 )
 
 //func init() {
@@ -10,24 +14,73 @@ import (
 //}
 
 func main() {
+	seed := flag.Int64("seed", 0, "seed used for every random decision, 0 picks a random one")
+	record := flag.String("record", "", "record the session inputs to this file")
+	replayFile := flag.String("replay", "", "replay a session recorded with -record")
+	diagonal := flag.Bool("diagonal", false, "let the player and the monsters move diagonally")
+	players := flag.Int("players", 1, "number of heroes of the party, played in turn from the window with Tab passing the seat")
+	logFile := flag.String("log", "", "append the message log of every run to this file when the run ends")
+	dataDir := flag.String("data", "", "directory holding the maps, defs and assets directories to use instead of the embedded ones")
+	flag.Parse()
 
-	// For multiple UI but doesn't work on MAC because of sdl.PollEvents
-	//game := game.NewGame(1, "game/maps/level1.map")
-	//
-	//for i := 0; i < 1; i++ {
-	//	go func(i int) {
-	//		runtime.LockOSThread()
-	//		ui := ui2d.NewUI(game.InputChan, game.LevelChans[i])
-	//		ui.Run()
-	//	}(i)
-	//}
-	//game.Run()
+	options := make([]game.Option, 0)
+	var assets fs.FS = ui2d.DefaultAssets
+	if *dataDir != "" {
+		data := os.DirFS(*dataDir)
+		options = append(options, game.WithData(data))
+		assets = data
+	}
+	if *seed != 0 {
+		options = append(options, game.WithSeed(*seed))
+	}
+	if *diagonal {
+		options = append(options, game.WithDiagonalMoves())
+	}
+	if *players > 1 {
+		options = append(options, game.WithPlayers(*players))
+	}
+	if *logFile != "" {
+		options = append(options, game.WithLogFile(*logFile))
+	}
 
-	game := game.NewGame(1)
+	var replay *game.Replay
+	if *replayFile != "" {
+		file, err := os.Open(*replayFile)
+		game.CheckError(err)
+		replay, err = game.ReadReplay(file)
+		game.CheckError(err)
+		game.CheckError(file.Close())
+		options = append(options, game.WithSeed(replay.Seed), game.WithClass(replay.Class))
+		if replay.Diagonal {
+			options = append(options, game.WithDiagonalMoves())
+		}
+		if replay.Players > 1 {
+			options = append(options, game.WithPlayers(replay.Players))
+		}
+	}
+
+	if *record != "" {
+		file, err := os.Create(*record)
+		game.CheckError(err)
+		defer file.Close()
+		options = append(options, game.WithRecorder(file))
+	}
+
+	game := game.NewGame(1, options...)
+
+	// the game reports the definitions errors itself when it starts, the ui then only shows them
+	defs, _ := game.Defs()
 
 	go game.Run()
 
-	ui := ui2d.NewUI(game.InputChan, game.LevelChans[0])
+	ui := ui2d.NewUI(assets, defs, game.InputChan, game.LevelChans[0], game.ErrorChan)
+	if *players > 1 {
+		ui.HotSeat()
+	}
+	if replay != nil {
+		ui.Spectate()
+		go replay.Feed(game.InputChan, 200*time.Millisecond)
+	}
 	ui.Run()
 
 }
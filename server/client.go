@@ -0,0 +1,124 @@
+package server
+
+import (
+	"AirPygee/game"
+	"encoding/json"
+	"errors"
+	"net"
+)
+
+// Client is a connection to a server, playing a hero of its game or watching it
+type Client struct {
+	conn    net.Conn
+	encoder *json.Encoder
+	decoder *json.Decoder
+	// Player is the ID of the hero played, given by the server when the client joined
+	Player    int
+	Spectator bool
+	// State is the game as the client knows it from the deltas received so far
+	State *State
+}
+
+// Dial connects a client to the server at the TCP address addr, it still has to join the game
+func Dial(addr string) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// NewClient returns a client talking to a server through conn
+func NewClient(conn net.Conn) *Client {
+	return &Client{conn: conn, encoder: json.NewEncoder(conn), decoder: json.NewDecoder(conn), State: &State{}}
+}
+
+// Join joins the game of the server, the client then plays the hero it was given or watches the game
+func (c *Client) Join() error {
+	if err := c.encoder.Encode(&ClientMessage{Type: JoinMessage, Version: Version}); err != nil {
+		return err
+	}
+	var message ServerMessage
+	if err := c.decoder.Decode(&message); err != nil {
+		return err
+	}
+	if message.Type != WelcomeMessage {
+		return errors.New(message.Error)
+	}
+	c.Player = message.Player
+	c.Spectator = message.Spectator
+	return nil
+}
+
+// Send sends an action of the hero of the client
+func (c *Client) Send(input Input) error {
+	return c.encoder.Encode(&ClientMessage{Type: InputMessage, Input: &input})
+}
+
+// Receive waits for the next state sent by the server and applies it to the state of the client
+func (c *Client) Receive() (*Delta, error) {
+	for {
+		var message ServerMessage
+		if err := c.decoder.Decode(&message); err != nil {
+			return nil, err
+		}
+		switch message.Type {
+		case StateMessage:
+			if message.State == nil {
+				continue
+			}
+			c.State.apply(message.State)
+			return message.State, nil
+		case ErrorMessage:
+			return nil, errors.New(message.Error)
+		}
+	}
+}
+
+// Leave leaves the game, the hero of the client waits for another player
+func (c *Client) Leave() error {
+	return c.encoder.Encode(&ClientMessage{Type: LeaveMessage})
+}
+
+// Close closes the connection, leaving the game if the client didn't leave it before
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// State is what a client knows of the game, the tiles it has seen and what its hero sees right now
+type State struct {
+	Level    string
+	Turn     int
+	Width    int
+	Height   int
+	Tiles    [][]Tile
+	Hero     Hero
+	Heroes   []Actor
+	Monsters []Actor
+	Items    []Item
+	Messages []game.Message
+}
+
+// apply updates the state with what changed in delta
+func (state *State) apply(delta *Delta) {
+	if delta.Full || state.Tiles == nil {
+		state.Tiles = make([][]Tile, delta.Height)
+		for y := range state.Tiles {
+			state.Tiles[y] = make([]Tile, delta.Width)
+			for x := range state.Tiles[y] {
+				state.Tiles[y][x].Pos = game.Pos{X: x, Y: y}
+			}
+		}
+	}
+	for _, tile := range delta.Tiles {
+		if tile.Y >= 0 && tile.Y < len(state.Tiles) && tile.X >= 0 && tile.X < len(state.Tiles[tile.Y]) {
+			state.Tiles[tile.Y][tile.X] = tile
+		}
+	}
+	state.Level, state.Turn, state.Width, state.Height = delta.Level, delta.Turn, delta.Width, delta.Height
+	state.Hero, state.Heroes, state.Monsters, state.Items = delta.Hero, delta.Heroes, delta.Monsters, delta.Items
+	if delta.ResetMessages {
+		state.Messages = nil
+	}
+	state.Messages = append(state.Messages, delta.Messages...)
+}
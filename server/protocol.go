@@ -0,0 +1,113 @@
+package server
+
+import (
+	"AirPygee/game"
+)
+
+// Version is the version of the protocol, clients have to join with the same one.
+// Every message is a JSON object on its own line
+const Version = 1
+
+// client message types
+const (
+	JoinMessage  = "join"
+	InputMessage = "input"
+	LeaveMessage = "leave"
+)
+
+// server message types
+const (
+	WelcomeMessage = "welcome"
+	StateMessage   = "state"
+	ErrorMessage   = "error"
+)
+
+// ClientMessage is what a client sends to the server, it first joins the game then sends the inputs of its hero
+type ClientMessage struct {
+	Type    string `json:"type"`
+	Version int    `json:"version,omitempty"`
+	Input   *Input `json:"input,omitempty"`
+}
+
+// Input is an action of the hero of a client, items are referenced by where they are relative to the hero
+type Input struct {
+	Type  game.InputType `json:"type"`
+	Item  *game.ItemRef  `json:"item,omitempty"`
+	Spell int            `json:"spell,omitempty"`
+}
+
+// ServerMessage is what the server sends to a client, the welcome once it joined then the state of every turn
+type ServerMessage struct {
+	Type    string `json:"type"`
+	Version int    `json:"version,omitempty"`
+	// Player is the ID of the hero of the client, spectators only watch the game
+	Player    int    `json:"player"`
+	Spectator bool   `json:"spectator,omitempty"`
+	State     *Delta `json:"state,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Delta is what changed of the game for a hero since the last state sent, limited to what the hero sees.
+// Tiles are only sent when they changed, the characters and items in sight are sent whole as they are few
+type Delta struct {
+	// Full tells the client to forget what it knew of the level, sent for the first state and when the level changed
+	Full   bool   `json:"full,omitempty"`
+	Level  string `json:"level"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Turn   int    `json:"turn"`
+	Tiles  []Tile `json:"tiles,omitempty"`
+	Hero   Hero   `json:"hero"`
+	// Heroes are the other heroes of the party in sight
+	Heroes   []Actor `json:"heroes,omitempty"`
+	Monsters []Actor `json:"monsters,omitempty"`
+	Items    []Item  `json:"items,omitempty"`
+	// Events are the events of the turn the hero saw, Messages the new messages of the log about what it saw.
+	// ResetMessages tells the client a new run started, the messages it kept are from the previous one
+	Events        []game.TurnEvent `json:"events,omitempty"`
+	Messages      []game.Message   `json:"messages,omitempty"`
+	ResetMessages bool             `json:"resetMessages,omitempty"`
+}
+
+// Tile is a tile of the level as the hero knows it, a tile never seen only has its position
+type Tile struct {
+	game.Pos
+	Rune     rune `json:"rune"`
+	Overlay  rune `json:"overlay,omitempty"`
+	Visible  bool `json:"visible,omitempty"`
+	Seen     bool `json:"seen,omitempty"`
+	Walkable bool `json:"walkable,omitempty"`
+}
+
+// Actor is a character in sight
+type Actor struct {
+	game.Pos
+	Name      string   `json:"name"`
+	Rune      rune     `json:"rune"`
+	Health    int      `json:"health"`
+	MaxHealth int      `json:"maxHealth"`
+	Effects   []string `json:"effects,omitempty"`
+	// Away tells a hero no one plays, it waits for a player to join
+	Away bool `json:"away,omitempty"`
+}
+
+// Hero is the hero of the client with what only its player knows
+type Hero struct {
+	Actor
+	ID        int      `json:"id"`
+	Mana      int      `json:"mana"`
+	MaxMana   int      `json:"maxMana"`
+	XP        int      `json:"xp"`
+	XPLevel   int      `json:"xpLevel"`
+	Satiation int      `json:"satiation"`
+	Ready     bool     `json:"ready"`
+	Items     []string `json:"items,omitempty"`
+	Equipped  []string `json:"equipped,omitempty"`
+}
+
+// Item is an item lying on a tile in sight
+type Item struct {
+	game.Pos
+	Name string `json:"name"`
+	Rune rune   `json:"rune"`
+}
@@ -0,0 +1,317 @@
+package server
+
+import (
+	"AirPygee/game"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// writeTimeout is how long a client has to read a message before it is dropped
+const writeTimeout = 10 * time.Second
+
+// maxPending is the number of states a client can be late on before it is dropped
+const maxPending = 64
+
+// playable are the inputs a client can send for its hero, the game itself is run by the server
+var playable = map[game.InputType]bool{
+	game.Up: true, game.Down: true, game.Left: true, game.Right: true,
+	game.UpLeft: true, game.UpRight: true, game.DownLeft: true, game.DownRight: true,
+	game.Action: true, game.TakeAll: true, game.TakeItem: true, game.Equip: true, game.Drop: true,
+	game.UseAbility: true, game.CastSpell: true, game.Fire: true,
+}
+
+// Server exposes a game to clients over network connections, every client playing a hero of the party.
+// The game has to be running, created with game.WithOpenSeats so heroes wait for their players
+type Server struct {
+	game *game.Game
+	mu   sync.Mutex
+	// seats are the heroes played by a client
+	seats map[int]*client
+}
+
+// New returns a server for g, which runs the game loop on its own with g.Run
+func New(g *game.Game) *Server {
+	return &Server{game: g, seats: make(map[int]*client)}
+}
+
+// ListenAndServe serves the game to the clients connecting on the TCP address addr
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve serves the game to every client connecting on listener until it is closed
+func (s *Server) Serve(listener net.Listener) error {
+	defer listener.Close()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// Loopback returns a client connected to the server without going through the network
+func (s *Server) Loopback() *Client {
+	serverConn, clientConn := net.Pipe()
+	go s.handle(serverConn)
+	return NewClient(clientConn)
+}
+
+// client is a connection playing a hero, the game sends it a level every turn which is sent back as a delta
+type client struct {
+	server    *Server
+	conn      net.Conn
+	encoder   *json.Encoder
+	player    int
+	spectator bool
+	levels    chan *game.Level
+
+	// pending are the levels received from the game and not sent yet, cond signals new ones
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []*game.Level
+	closed  bool
+
+	leaveOnce sync.Once
+	// what was sent to the client so far, messages being the part of the log of the run already looked at
+	sent     bool
+	level    string
+	tiles    [][]Tile
+	run      int
+	messages int
+}
+
+// handle plays the session of a connection, from its join to its leave
+func (s *Server) handle(conn net.Conn) {
+	c := &client{server: s, conn: conn, encoder: json.NewEncoder(conn), levels: make(chan *game.Level)}
+	c.cond = sync.NewCond(&c.mu)
+	decoder := json.NewDecoder(conn)
+
+	var join ClientMessage
+	if err := decoder.Decode(&join); err != nil {
+		conn.Close()
+		return
+	}
+	if join.Type != JoinMessage || join.Version != Version {
+		c.write(&ServerMessage{Type: ErrorMessage, Version: Version, Error: fmt.Sprintf("expected a join message of version %d", Version)})
+		conn.Close()
+		return
+	}
+
+	s.sit(c)
+	if err := c.write(&ServerMessage{Type: WelcomeMessage, Version: Version, Player: c.player, Spectator: c.spectator}); err != nil {
+		// the game doesn't know c yet, only its seat has to be given back
+		s.stand(c)
+		conn.Close()
+		return
+	}
+	go c.receive()
+	go c.send()
+	s.game.InputChan <- &game.Input{Typ: game.Join, LevelChannel: c.levels, Player: c.player}
+
+	for {
+		var message ClientMessage
+		if err := decoder.Decode(&message); err != nil || message.Type == LeaveMessage {
+			break
+		}
+		if message.Type != InputMessage || message.Input == nil || c.spectator || !playable[message.Input.Type] {
+			continue
+		}
+		s.game.InputChan <- &game.Input{Typ: message.Input.Type, ItemRef: message.Input.Item, Spell: message.Input.Spell, Player: c.player}
+	}
+	c.leave()
+}
+
+// sit gives c the first hero no one plays, c only watches the game when every hero is played
+func (s *Server) sit(c *client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id := 0; id < s.game.NumPlayers; id++ {
+		if s.seats[id] == nil {
+			s.seats[id] = c
+			c.player = id
+			return
+		}
+	}
+	// spectators follow the first hero alive
+	c.player = -1
+	c.spectator = true
+}
+
+// stand frees the hero c sits at so another client can play it
+func (s *Server) stand(c *client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !c.spectator && s.seats[c.player] == c {
+		delete(s.seats, c.player)
+	}
+}
+
+// leave frees the hero of c and removes it from the game, the levels are received until the game closes the channel
+func (c *client) leave() {
+	c.leaveOnce.Do(func() {
+		c.server.stand(c)
+		c.conn.Close()
+		// the game may be sending c a level, so the leave is sent without waiting
+		go func() {
+			c.server.game.InputChan <- &game.Input{Typ: game.Leave, LevelChannel: c.levels}
+		}()
+	})
+}
+
+// receive takes the levels the game sends to c without ever keeping the game waiting
+func (c *client) receive() {
+	for level := range c.levels {
+		c.mu.Lock()
+		c.pending = append(c.pending, level)
+		late := len(c.pending) > maxPending
+		c.cond.Signal()
+		c.mu.Unlock()
+		if late {
+			c.leave()
+		}
+	}
+	c.mu.Lock()
+	c.closed = true
+	c.cond.Signal()
+	c.mu.Unlock()
+}
+
+// send writes the states of the levels received to the connection
+func (c *client) send() {
+	for {
+		c.mu.Lock()
+		for len(c.pending) == 0 && !c.closed {
+			c.cond.Wait()
+		}
+		levels := c.pending
+		c.pending = nil
+		closed := c.closed
+		c.mu.Unlock()
+
+		for _, level := range levels {
+			if err := c.write(&ServerMessage{Type: StateMessage, Player: c.player, Spectator: c.spectator, State: c.delta(level)}); err != nil {
+				c.leave()
+				return
+			}
+		}
+		if closed {
+			return
+		}
+	}
+}
+
+func (c *client) write(message *ServerMessage) error {
+	if err := c.conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+	return c.encoder.Encode(message)
+}
+
+// delta returns what changed of level since the last state sent to c, level being seen by the hero of c
+func (c *client) delta(level *game.Level) *Delta {
+	delta := &Delta{Level: level.Name, Height: len(level.Map), Turn: level.Log.Turn(), Hero: newHero(level.Player)}
+	if len(level.Map) > 0 {
+		delta.Width = len(level.Map[0])
+	}
+	if c.tiles == nil || level.Name != c.level || len(c.tiles) != delta.Height || len(c.tiles[0]) != delta.Width {
+		delta.Full = true
+		c.level = level.Name
+		c.tiles = make([][]Tile, delta.Height)
+		for y := range c.tiles {
+			c.tiles[y] = make([]Tile, delta.Width)
+			for x := range c.tiles[y] {
+				c.tiles[y][x].Pos = game.Pos{X: x, Y: y}
+			}
+		}
+	}
+
+	for y, row := range level.Map {
+		for x, tile := range row {
+			known := Tile{Pos: game.Pos{X: x, Y: y}}
+			if tile.Seen || tile.Visible {
+				known.Rune, known.Overlay, known.Visible, known.Seen, known.Walkable = tile.Rune, tile.OverlayRune, tile.Visible, tile.Seen, tile.Walkable
+			}
+			if known != c.tiles[y][x] {
+				delta.Tiles = append(delta.Tiles, known)
+				c.tiles[y][x] = known
+			}
+		}
+	}
+
+	visible := func(pos game.Pos) bool {
+		return pos.Y >= 0 && pos.Y < len(level.Map) && pos.X >= 0 && pos.X < len(level.Map[pos.Y]) && level.Map[pos.Y][pos.X].Visible
+	}
+	for _, hero := range level.Players {
+		if hero != level.Player && visible(hero.Pos) {
+			actor := newActor(&hero.Character)
+			actor.Away = hero.Away
+			delta.Heroes = append(delta.Heroes, actor)
+		}
+	}
+	for pos, monster := range level.Monsters {
+		if visible(pos) {
+			delta.Monsters = append(delta.Monsters, newActor(&monster.Character))
+		}
+	}
+	for pos, items := range level.Items {
+		if !visible(pos) {
+			continue
+		}
+		for _, item := range items {
+			delta.Items = append(delta.Items, Item{Pos: pos, Name: item.GetName(), Rune: item.GetRune()})
+		}
+	}
+	for _, event := range level.TurnEvents {
+		if visible(event.Pos) || event.ActorID == level.Player.CharacterID() || event.TargetID == level.Player.CharacterID() {
+			delta.Events = append(delta.Events, event)
+		}
+	}
+
+	// the log starts again with every new run
+	if c.sent && level.Log.Run() != c.run {
+		delta.ResetMessages = true
+		c.messages = 0
+	}
+	for _, message := range level.Log.Messages[c.messages:] {
+		if visible(message.Pos) {
+			delta.Messages = append(delta.Messages, message)
+		}
+	}
+	c.messages = len(level.Log.Messages)
+	c.run = level.Log.Run()
+	c.sent = true
+	return delta
+}
+
+func newActor(c *game.Character) Actor {
+	actor := Actor{Pos: c.Pos, Name: c.Name, Rune: c.Rune, Health: c.Health, MaxHealth: c.MaxHealth}
+	for _, effect := range c.Effects {
+		actor.Effects = append(actor.Effects, effect.Name)
+	}
+	return actor
+}
+
+func newHero(p *game.Player) Hero {
+	hero := Hero{Actor: newActor(&p.Character), ID: p.ID, Mana: p.Mana, MaxMana: p.MaxMana, XP: p.XP, XPLevel: p.XPLevel, Satiation: p.Satiation, Ready: p.Ready()}
+	for _, item := range p.Items {
+		hero.Items = append(hero.Items, item.GetName())
+	}
+	for _, item := range p.EquippedItems {
+		hero.Equipped = append(hero.Equipped, item.GetName())
+	}
+	hero.Away = p.Away
+	return hero
+}
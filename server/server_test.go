@@ -0,0 +1,166 @@
+package server
+
+import (
+	"AirPygee/game"
+	"testing"
+)
+
+func newServer(options ...game.Option) *Server {
+	g := game.NewGame(0, append([]game.Option{game.WithOpenSeats(), game.WithPlayers(2), game.WithSeed(1)}, options...)...)
+	go g.Run()
+	return New(g)
+}
+
+func join(t *testing.T, s *Server) *Client {
+	c := s.Loopback()
+	if err := c.Join(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// drain keeps reading the states sent to c so the server never waits for it
+func drain(c *Client) {
+	go func() {
+		for {
+			if _, err := c.Receive(); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+// receiveUntil reads the states sent to c until done is true for one of them
+func receiveUntil(t *testing.T, c *Client, done func(*Delta) bool) *Delta {
+	for i := 0; i < 100; i++ {
+		delta, err := c.Receive()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if done(delta) {
+			return delta
+		}
+	}
+	t.Fatal("the expected state never came")
+	return nil
+}
+
+// free tells if the hero of state can walk to pos
+func free(state *State, pos game.Pos) bool {
+	if pos.Y < 0 || pos.Y >= len(state.Tiles) || pos.X < 0 || pos.X >= len(state.Tiles[pos.Y]) || !state.Tiles[pos.Y][pos.X].Walkable {
+		return false
+	}
+	for _, actors := range [][]Actor{state.Heroes, state.Monsters} {
+		for _, actor := range actors {
+			if actor.Pos == pos {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestWrongVersion(t *testing.T) {
+	c := newServer().Loopback()
+	defer c.Close()
+	if err := c.encoder.Encode(&ClientMessage{Type: JoinMessage, Version: Version + 1}); err != nil {
+		t.Fatal(err)
+	}
+	var message ServerMessage
+	if err := c.decoder.Decode(&message); err != nil {
+		t.Fatal(err)
+	}
+	if message.Type != ErrorMessage || message.Error == "" {
+		t.Errorf("got a %q message, want an error", message.Type)
+	}
+}
+
+func TestSeats(t *testing.T) {
+	s := newServer()
+	first, second, third := join(t, s), join(t, s), join(t, s)
+	defer first.Close()
+	defer second.Close()
+	defer third.Close()
+	if first.Player != 0 || first.Spectator || second.Player != 1 || second.Spectator {
+		t.Errorf("got seats %d and %d, want 0 and 1", first.Player, second.Player)
+	}
+	if !third.Spectator {
+		t.Error("the third client plays, it should only watch the game")
+	}
+}
+
+func TestMoveAndLeave(t *testing.T) {
+	s := newServer()
+	first, second := join(t, s), join(t, s)
+	defer first.Close()
+	drain(second)
+
+	delta := receiveUntil(t, first, func(delta *Delta) bool { return delta.Full })
+	moves := map[game.InputType]game.Pos{game.Up: {X: 0, Y: -1}, game.Down: {X: 0, Y: 1}, game.Left: {X: -1, Y: 0}, game.Right: {X: 1, Y: 0}}
+	moved := false
+	for input, dir := range moves {
+		to := game.Pos{X: delta.Hero.X + dir.X, Y: delta.Hero.Y + dir.Y}
+		if !free(first.State, to) {
+			continue
+		}
+		if err := first.Send(Input{Type: input}); err != nil {
+			t.Fatal(err)
+		}
+		receiveUntil(t, first, func(delta *Delta) bool { return delta.Hero.Pos == to })
+		moved = true
+		break
+	}
+	if !moved {
+		t.Fatal("the hero has no free tile to move to")
+	}
+
+	if err := second.Leave(); err != nil {
+		t.Fatal(err)
+	}
+	receiveUntil(t, first, func(delta *Delta) bool {
+		for _, hero := range delta.Heroes {
+			if hero.Away {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func TestMalformedInputs(t *testing.T) {
+	c := join(t, newServer(game.WithClass("Warrior")))
+	defer c.Close()
+	receiveUntil(t, c, func(delta *Delta) bool { return delta.Full })
+
+	// the warrior puts down its sword and carries its plate so it has an item on the ground and one in its bag
+	for _, input := range []Input{
+		{Type: game.Equip, Item: &game.ItemRef{Where: game.InEquipped, Index: 0}},
+		{Type: game.Equip, Item: &game.ItemRef{Where: game.InEquipped, Index: 0}},
+		{Type: game.Drop, Item: &game.ItemRef{Where: game.InInventory, Index: 0}},
+	} {
+		if err := c.Send(input); err != nil {
+			t.Fatal(err)
+		}
+	}
+	receiveUntil(t, c, func(delta *Delta) bool { return len(delta.Hero.Items) == 1 && len(delta.Items) > 0 })
+
+	bad := []Input{
+		{Type: game.Equip},
+		{Type: game.Drop, Item: &game.ItemRef{Where: game.OnGround, Index: 0}},
+		{Type: game.TakeItem, Item: &game.ItemRef{Where: game.InInventory, Index: 0}},
+	}
+	for _, input := range bad {
+		if err := c.Send(input); err != nil {
+			t.Fatal(err)
+		}
+	}
+	receiveUntil(t, c, func(*Delta) bool {
+		refused := 0
+		for _, message := range c.State.Messages {
+			if message.Text == "That item can't be used this way" {
+				refused++
+			}
+		}
+		return refused == len(bad)
+	})
+}
@@ -0,0 +1,57 @@
+package ui2d
+
+import (
+	"AirPygee/game"
+	"embed"
+	"github.com/veandco/go-sdl2/img"
+	"github.com/veandco/go-sdl2/mix"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+	"io/fs"
+)
+
+//go:embed assets
+var embeddedAssets embed.FS
+
+// DefaultAssets holds the textures, fonts and sounds baked into the binary, under assets/
+var DefaultAssets fs.FS = embeddedAssets
+
+func (ui *ui) readAsset(name string) []byte {
+	data, err := fs.ReadFile(ui.assets, name)
+	game.CheckError(err)
+	return data
+}
+
+func (ui *ui) assetRW(name string) *sdl.RWops {
+	rw, err := sdl.RWFromMem(ui.readAsset(name))
+	game.CheckError(err)
+	return rw
+}
+
+// streamedAssetRW is used for the music and the fonts which keep reading their data after being opened,
+// the data is then kept alive as long as the ui
+func (ui *ui) streamedAssetRW(name string) *sdl.RWops {
+	data := ui.readAsset(name)
+	ui.streamedAssets = append(ui.streamedAssets, data)
+	rw, err := sdl.RWFromMem(data)
+	game.CheckError(err)
+	return rw
+}
+
+func (ui *ui) loadImage(name string) *sdl.Surface {
+	image, err := img.LoadRW(ui.assetRW(name), true)
+	game.CheckError(err)
+	return image
+}
+
+func (ui *ui) loadMusic(name string) *mix.Music {
+	music, err := mix.LoadMUSRW(ui.streamedAssetRW(name), 1)
+	game.CheckError(err)
+	return music
+}
+
+func (ui *ui) loadFont(name string, size int) *ttf.Font {
+	font, err := ttf.OpenFontRW(ui.streamedAssetRW(name), 1, size)
+	game.CheckError(err)
+	return font
+}
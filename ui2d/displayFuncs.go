@@ -72,6 +72,84 @@ func (ui *ui) displayStats(level *game.Level) {
 
 	err = ui.renderer.Copy(tex, nil, &sdl.Rect{X: int32(float64(panelWidth) * .5), Y: statsPanelOffsetY + int32(float64(panelHeight)*.45), W: w, H: h})
 	game.CheckError(err)
+
+	// Drawing player level
+	tex = ui.stringToTexture("Level:", color, FontSmall)
+	_, _, w, h, _ = tex.Query()
+
+	err = ui.renderer.Copy(tex, nil, &sdl.Rect{X: int32(float64(panelWidth) * .15), Y: statsPanelOffsetY + int32(float64(panelHeight)*.55), W: w, H: h})
+	game.CheckError(err)
+
+	tex = ui.stringToTexture(fmt.Sprintf("%v", level.Player.XPLevel), statsColor, FontSmall)
+	_, _, w, h, _ = tex.Query()
+
+	err = ui.renderer.Copy(tex, nil, &sdl.Rect{X: int32(float64(panelWidth) * .5), Y: statsPanelOffsetY + int32(float64(panelHeight)*.55), W: w, H: h})
+	game.CheckError(err)
+
+	// Drawing XP bar, full at the last level
+	tex = ui.stringToTexture("XP:", color, FontSmall)
+	_, _, w, h, _ = tex.Query()
+
+	err = ui.renderer.Copy(tex, nil, &sdl.Rect{X: int32(float64(panelWidth) * .15), Y: statsPanelOffsetY + int32(float64(panelHeight)*.65), W: w, H: h})
+	game.CheckError(err)
+
+	gauge := 1.0
+	if level.Player.XPForNextLevel != 0 {
+		gauge = float64(level.Player.XP-level.Player.XPForLevel) / float64(level.Player.XPForNextLevel-level.Player.XPForLevel)
+	}
+	barWidth := int32(float64(panelWidth) * .35)
+	barRect := &sdl.Rect{X: int32(float64(panelWidth) * .5), Y: statsPanelOffsetY + int32(float64(panelHeight)*.65) + h/4, W: barWidth, H: h / 2}
+
+	err = ui.renderer.SetDrawColor(60, 60, 60, 255)
+	game.CheckError(err)
+	err = ui.renderer.FillRect(barRect)
+	game.CheckError(err)
+
+	barRect.W = int32(float64(barWidth) * gauge)
+	err = ui.renderer.SetDrawColor(color.R, color.G, color.B, color.A)
+	game.CheckError(err)
+	err = ui.renderer.FillRect(barRect)
+	game.CheckError(err)
+
+	err = ui.renderer.SetDrawColor(0, 0, 0, 0)
+	game.CheckError(err)
+
+	// Drawing Mana count
+	tex = ui.stringToTexture("Mana:", color, FontSmall)
+	_, _, w, h, _ = tex.Query()
+
+	err = ui.renderer.Copy(tex, nil, &sdl.Rect{X: int32(float64(panelWidth) * .15), Y: statsPanelOffsetY + int32(float64(panelHeight)*.75), W: w, H: h})
+	game.CheckError(err)
+
+	tex = ui.stringToTexture(fmt.Sprintf("%v/%v", level.Player.Mana, level.Player.MaxMana), statsColor, FontSmall)
+	_, _, w, h, _ = tex.Query()
+
+	err = ui.renderer.Copy(tex, nil, &sdl.Rect{X: int32(float64(panelWidth) * .5), Y: statsPanelOffsetY + int32(float64(panelHeight)*.75), W: w, H: h})
+	game.CheckError(err)
+
+	// Drawing hunger, only in survival games
+	if !level.Survival {
+		return
+	}
+	tex = ui.stringToTexture("Hunger:", color, FontSmall)
+	_, _, w, h, _ = tex.Query()
+
+	err = ui.renderer.Copy(tex, nil, &sdl.Rect{X: int32(float64(panelWidth) * .15), Y: statsPanelOffsetY + int32(float64(panelHeight)*.85), W: w, H: h})
+	game.CheckError(err)
+
+	tex = ui.stringToTexture(level.Player.Hunger(), hungerColors[level.Player.Hunger()], FontSmall)
+	_, _, w, h, _ = tex.Query()
+
+	err = ui.renderer.Copy(tex, nil, &sdl.Rect{X: int32(float64(panelWidth) * .5), Y: statsPanelOffsetY + int32(float64(panelHeight)*.85), W: w, H: h})
+	game.CheckError(err)
+}
+
+// hungerColors are the colors of the hunger states of the player
+var hungerColors = map[string]sdl.Color{
+	"Satiated": {R: 0, G: 150, B: 0, A: 255},
+	"Hungry":   {R: 255, G: 255, B: 0, A: 255},
+	"Weak":     {R: 255, G: 140, B: 0, A: 255},
+	"Starving": {R: 255, G: 0, B: 0, A: 255},
 }
 
 func (ui *ui) getColorFromHealth(health float64) (r, g, b, a uint8) {
@@ -177,7 +255,11 @@ func (ui *ui) displayPopupItem(item game.Item, mouseX, mouseY int32) {
 		err = ui.renderer.Copy(tex, nil, &sdl.Rect{X: mouseX - (popupWidth / 2) - (w / 2), Y: mouseY + int32(float64(popupHeight)*.05), W: w, H: h})
 		game.CheckError(err)
 
-		texPotion := ui.stringToTexture("Size: "+item.(game.ConsumableItem).GetSize(), color, FontSmall)
+		detail := "Size: " + item.(game.ConsumableItem).GetSize()
+		if food, ok := item.(*game.Food); ok {
+			detail = fmt.Sprintf("Nutrition: %d", food.Nutrition)
+		}
+		texPotion := ui.stringToTexture(detail, color, FontSmall)
 		_, _, w, h, _ = texPotion.Query()
 		err = ui.renderer.Copy(texPotion, nil, &sdl.Rect{X: mouseX - popupWidth, Y: mouseY + int32(float64(popupHeight)*.65), W: w, H: h})
 		game.CheckError(err)
@@ -238,19 +320,58 @@ func (ui *ui) displayMonsters(level *game.Level) {
 			err = ui.renderer.FillRect(&sdl.Rect{X: int32(level.Monsters[pos].X)*tileSize + ui.offsetX, Y: int32(level.Monsters[pos].Y-1)*tileSize + ui.offsetY + 20, W: int32(float64(tileSize) * gauge), H: 5})
 			game.CheckError(err)
 
-			ui.textureIndexMonsters.mu.RLock()
-			monsterSrcRect := ui.textureIndexMonsters.rects[monster.Rune][0]
-			ui.textureIndexMonsters.mu.RUnlock()
+			monsterSrcRect := ui.monsterRects(monster.Def)[0]
 
 			err = ui.renderer.SetDrawColor(0, 0, 0, 0)
 			game.CheckError(err)
 
 			err = ui.renderer.Copy(ui.textureAtlas, monsterSrcRect, &sdl.Rect{X: int32(pos.X)*tileSize + ui.offsetX, Y: int32(pos.Y)*tileSize + ui.offsetY, W: tileSize, H: tileSize})
 			game.CheckError(err)
+			ui.displayEffects(&monster.Character)
 		}
 	}
 }
 
+// effectColors are the colors of the status effect icons, effects missing here are drawn white
+var effectColors = map[string]sdl.Color{
+	"poison":       {R: 60, G: 200, B: 60, A: 255},
+	"bleed":        {R: 200, G: 0, B: 0, A: 255},
+	"stun":         {R: 255, G: 215, B: 0, A: 255},
+	"regeneration": {R: 255, G: 105, B: 180, A: 255},
+	"haste":        {R: 0, G: 200, B: 255, A: 255},
+	"slow":         {R: 120, G: 120, B: 160, A: 255},
+}
+
+// displayEffects draws an icon per status effect of c along the top of its tile
+func (ui *ui) displayEffects(c *game.Character) {
+	size := int32(tileSize / 4)
+	for i, effect := range c.Effects {
+		color, exists := effectColors[effect.Name]
+		if !exists {
+			color = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+		}
+		err := ui.renderer.SetDrawColor(color.R, color.G, color.B, color.A)
+		game.CheckError(err)
+		err = ui.renderer.FillRect(&sdl.Rect{X: int32(c.X)*tileSize + ui.offsetX + int32(i)*(size+1), Y: int32(c.Y)*tileSize + ui.offsetY, W: size, H: size})
+		game.CheckError(err)
+	}
+	err := ui.renderer.SetDrawColor(0, 0, 0, 0)
+	game.CheckError(err)
+}
+
+// monsterRects returns the atlas rects of a monster, computed from its definition the first time it is displayed
+func (ui *ui) monsterRects(def *game.MonsterDef) []*sdl.Rect {
+	ui.textureIndexMonsters.mu.Lock()
+	defer ui.textureIndexMonsters.mu.Unlock()
+
+	rects, exists := ui.textureIndexMonsters.rects[def.Glyph]
+	if !exists {
+		rects = atlasRects(def.AtlasX, def.AtlasY, def.AtlasVariations)
+		ui.textureIndexMonsters.rects[def.Glyph] = rects
+	}
+	return rects
+}
+
 // displayItems displays items on Map
 func (ui *ui) displayItems(level *game.Level) {
 	for pos, items := range level.Items {
@@ -292,7 +413,43 @@ func (ui *ui) displayItems(level *game.Level) {
 	}
 }
 
-// displayEvents for drawing event list during game
+// eventLines is the number of last messages shown during game
+const eventLines = 15
+
+// displayHeroes draws the heroes of the party the player sees with their health, and the name of the player when there are several
+func (ui *ui) displayHeroes(level *game.Level) {
+	if len(level.Players) < 2 {
+		return
+	}
+	if ui.pClass == nil || ui.pClass.Name != level.Player.Class.Name {
+		ui.LoadPlayer(level.Player.Class)
+	}
+	for _, hero := range level.Players {
+		if hero == level.Player || !level.Map[hero.Y][hero.X].Visible {
+			continue
+		}
+		gauge := float64(hero.Health) / float64(hero.MaxHealth)
+		err := ui.renderer.SetDrawColor(ui.getColorFromHealth(gauge))
+		game.CheckError(err)
+		err = ui.renderer.FillRect(&sdl.Rect{X: int32(hero.X)*tileSize + ui.offsetX, Y: int32(hero.Y-1)*tileSize + ui.offsetY + 20, W: int32(float64(tileSize) * gauge), H: 5})
+		game.CheckError(err)
+		err = ui.renderer.SetDrawColor(0, 0, 0, 0)
+		game.CheckError(err)
+
+		src := &sdl.Rect{X: ui.pSheetX, Y: ui.pSheetY, W: ui.pWidthTex, H: ui.pHeightTex}
+		dst := &sdl.Rect{X: int32(hero.X)*tileSize + ui.offsetX - (int32(float64(ui.pWidthTex)*1.25) - tileSize), Y: int32(hero.Y)*tileSize + ui.offsetY - (int32(float64(ui.pHeightTex)*1.25) - tileSize), W: int32(float64(ui.pWidthTex) * 1.25), H: int32(float64(ui.pHeightTex) * 1.25)}
+		err = ui.renderer.Copy(ui.pTextureSheet, src, dst)
+		game.CheckError(err)
+		ui.displayEffects(&hero.Character)
+	}
+
+	tex := ui.stringToTexture(level.Player.Name, sdl.Color{R: 255, G: 215, B: 0, A: 255}, FontMedium)
+	_, _, w, h, _ := tex.Query()
+	err := ui.renderer.Copy(tex, nil, &sdl.Rect{X: (int32(ui.winWidth) - w) / 2, Y: 0, W: w, H: h})
+	game.CheckError(err)
+}
+
+// displayEvents for drawing event list during game, the last messages of the log
 func (ui *ui) displayEvents(level *game.Level) {
 	textStartX := int32(float64(ui.winWidth) * .015)
 	textStartY := int32(float64(ui.winHeight) * .68)
@@ -302,27 +459,14 @@ func (ui *ui) displayEvents(level *game.Level) {
 	err := ui.renderer.Copy(ui.uipack, ui.getRectFromTextureName("panel_beige.png"), &sdl.Rect{X: 0, Y: int32(ui.winHeight) - (int32(ui.winHeight) - textStartY + int32(fontSizeY)), W: textWidth, H: int32(ui.winHeight) - textStartY + int32(fontSizeY)})
 	game.CheckError(err)
 
-	i := level.EventPos
-	count := 0
-
-	for {
-		event := level.Events[i]
-		if event != "" {
-			tex := ui.stringToTexture(event, sdl.Color{R: 100, G: 50}, FontSmall)
-			_, _, w, h, _ := tex.Query()
-			err = ui.renderer.Copy(tex, nil, &sdl.Rect{X: textStartX, Y: int32(count*fontSizeY) + (int32(ui.winHeight) - (int32(ui.winHeight) - textStartY)), W: w, H: h})
-			game.CheckError(err)
-
-		}
-		i = (i + 1) % (len(level.Events))
-		count++
-		if i == level.EventPos {
-			break
-		}
+	for count, message := range level.Log.Last(eventLines) {
+		ui.drawText(message.Text, messageColor(message), textStartX, int32(count*fontSizeY)+(int32(ui.winHeight)-(int32(ui.winHeight)-textStartY)))
 	}
 }
 
 func (ui *ui) displayDamages() {
+	ui.animMu.Lock()
+	defer ui.animMu.Unlock()
 	for _, damage := range ui.damagesToDisplay {
 		_, _, w, h, _ := damage.tex.Query()
 		err := ui.renderer.Copy(damage.tex, nil, &sdl.Rect{X: int32(damage.pos.X)*tileSize + ui.offsetX, Y: int32(damage.pos.Y)*tileSize + ui.offsetY, W: w, H: h})
@@ -331,8 +475,10 @@ func (ui *ui) displayDamages() {
 }
 
 // displayTileAnimation if passed a zero duration, will only play all frames once
-func (ui *ui) displayTileAnimation(level *game.Level, duration time.Duration, tick time.Duration, p game.Pos, animation rune, textureIndex *TextureIndex, tex *sdl.Texture) {
-	tempTile := level.Map[p.Y][p.X].AnimRune
+func (ui *ui) displayTileAnimation(duration time.Duration, tick time.Duration, p game.Pos, animation rune, textureIndex *TextureIndex, tex *sdl.Texture) {
+	ui.animMu.Lock()
+	tempTile, hadAnim := ui.tileAnims[p]
+	ui.animMu.Unlock()
 	numFrames := len(textureIndex.rects[animation])
 	started := true
 	currentFrame := 0
@@ -340,17 +486,19 @@ func (ui *ui) displayTileAnimation(level *game.Level, duration time.Duration, ti
 
 	for range time.Tick(tick) {
 		textureIndex.mu.RLock()
-
-		ui.animations[animation] = &Animation{
+		frame := &Animation{
 			rect: textureIndex.rects[animation][currentFrame],
 			tex:  tex,
 		}
 		textureIndex.mu.RUnlock()
 
+		ui.animMu.Lock()
+		ui.animations[animation] = frame
 		if started {
-			level.Map[p.Y][p.X].AnimRune = animation
+			ui.tileAnims[p] = animation
 			started = false
 		}
+		ui.animMu.Unlock()
 
 		currentFrame++
 		if currentFrame == numFrames {
@@ -364,7 +512,13 @@ func (ui *ui) displayTileAnimation(level *game.Level, duration time.Duration, ti
 			break
 		}
 	}
-	level.Map[p.Y][p.X].AnimRune = tempTile
+	ui.animMu.Lock()
+	if hadAnim {
+		ui.tileAnims[p] = tempTile
+	} else {
+		delete(ui.tileAnims, p)
+	}
+	ui.animMu.Unlock()
 }
 
 func (ui *ui) displayPlayerAnimation(duration time.Duration, tick time.Duration, animation rune, textureIndex *TextureIndex, tex *sdl.Texture) {
@@ -401,9 +555,17 @@ func (ui *ui) displayPlayerAnimation(duration time.Duration, tick time.Duration,
 	ui.pAnimated = false
 }
 
-func (ui *ui) displayMovingAnimation(level *game.Level, duration time.Duration, tick time.Duration, poss []game.Pos, animation rune, textureIndex *TextureIndex, tex *sdl.Texture) {
+func (ui *ui) displayMovingAnimation(duration time.Duration, tick time.Duration, poss []game.Pos, animation rune, textureIndex *TextureIndex, tex *sdl.Texture) {
 	for _, pos := range poss {
-		ui.displayTileAnimation(level, duration, tick, pos, animation, textureIndex, tex)
+		ui.displayTileAnimation(duration, tick, pos, animation, textureIndex, tex)
+	}
+}
+
+// displaySpellAnimation moves the projectile of spell along its path, then animates its impacts
+func (ui *ui) displaySpellAnimation(spell game.GameSpell) {
+	ui.displayMovingAnimation(50*time.Millisecond, 50*time.Millisecond, spell.Path, game.ProjectileAnim, &ui.textureIndexAnims, ui.textureAtlas)
+	for _, pos := range spell.Impacts {
+		go ui.displayTileAnimation(300*time.Millisecond, 100*time.Millisecond, pos, game.ImpactAnim, &ui.textureIndexAnims, ui.textureAtlas)
 	}
 }
 
@@ -411,9 +573,13 @@ func (ui *ui) addAttackResult(damage int, duration time.Duration, isCritical boo
 	now := time.Now().String()
 
 	tex := ui.stringToTexture(strconv.Itoa(damage), sdl.Color{R: 255}, FontMedium)
+	ui.animMu.Lock()
 	ui.damagesToDisplay[now] = &Damage{pos: p, tex: tex, isCritical: isCritical}
+	ui.animMu.Unlock()
 	for start := time.Now(); time.Since(start) < duration; {
 
 	}
+	ui.animMu.Lock()
 	delete(ui.damagesToDisplay, now)
+	ui.animMu.Unlock()
 }
@@ -2,37 +2,41 @@ package ui2d
 
 import (
 	"AirPygee/game"
-	"github.com/veandco/go-sdl2/img"
 	"github.com/veandco/go-sdl2/sdl"
 )
 
-func (ui *ui) LoadPlayer() {
+// LoadPlayer loads the sprite sheet of class, sheets hold 4x2 characters made of 3 frames for each of the 4 directions
+func (ui *ui) LoadPlayer(class *game.ClassDef) {
 	ui.pCurrentFrame = 0
 	ui.pFramesX = 3
 	ui.pFramesY = 4
+	ui.pClass = class
 
-	image, err := img.Load("ui2d/assets/chara2.png")
-	game.CheckError(err)
+	image := ui.loadImage("assets/" + class.Sprite)
 	defer image.Free()
 
-	image.W /= 4
-	image.H /= 2
-
+	if ui.pTextureSheet != nil {
+		game.CheckError(ui.pTextureSheet.Destroy())
+	}
+	var err error
 	ui.pTextureSheet, err = ui.renderer.CreateTextureFromSurface(image)
 	game.CheckError(err)
 
-	_, _, imageWidth, imageHeight, _ := ui.pTextureSheet.Query()
-	ui.pWidthTex = imageWidth / ui.pFramesX
-	ui.pHeightTex = imageHeight / ui.pFramesY
+	characterWidth := image.W / 4
+	characterHeight := image.H / 2
+	ui.pSheetX = int32(class.SpriteCol) * characterWidth
+	ui.pSheetY = int32(class.SpriteRow) * characterHeight
+	ui.pWidthTex = characterWidth / ui.pFramesX
+	ui.pHeightTex = characterHeight / ui.pFramesY
 }
 
 func (ui *ui) LoadPlayerAnims() {
 	ui.pAnims.rects = make(map[rune][]*sdl.Rect)
 	squareSize := int32(144)
-	image, err := img.Load("ui2d/assets/chara2_anims.png")
-	game.CheckError(err)
+	image := ui.loadImage("assets/chara2_anims.png")
 	defer image.Free()
 
+	var err error
 	ui.pAnimSheet, err = ui.renderer.CreateTextureFromSurface(image)
 	game.CheckError(err)
 
@@ -71,18 +75,21 @@ func (ui *ui) UpdatePlayer(input game.InputType) {
 		ui.pFromY = 3 * ui.pHeightTex
 	case game.Down:
 		ui.pFromY = 0
-	case game.Left:
+	case game.Left, game.UpLeft, game.DownLeft:
 		ui.pFromY = ui.pHeightTex
-	case game.Right:
+	case game.Right, game.UpRight, game.DownRight:
 		ui.pFromY = 2 * ui.pHeightTex
 	}
 }
 
 func (ui *ui) drawPlayer(level *game.Level) {
 	p := level.Player
+	if ui.pClass == nil || ui.pClass.Name != p.Class.Name {
+		ui.LoadPlayer(p.Class)
+	}
 	ui.pFromX = ui.pCurrentFrame * ui.pWidthTex
 
-	ui.pSrc = sdl.Rect{X: ui.pFromX, Y: ui.pFromY, W: ui.pWidthTex, H: ui.pHeightTex}
+	ui.pSrc = sdl.Rect{X: ui.pSheetX + ui.pFromX, Y: ui.pSheetY + ui.pFromY, W: ui.pWidthTex, H: ui.pHeightTex}
 	ui.pDest = sdl.Rect{X: int32(p.X)*tileSize + ui.offsetX - (int32(float64(ui.pWidthTex)*1.25) - tileSize), Y: int32(p.Y)*tileSize + ui.offsetY - (int32(float64(ui.pHeightTex)*1.25) - tileSize), W: int32(float64(ui.pWidthTex) * 1.25), H: int32(float64(ui.pHeightTex) * 1.25)}
 
 	err := ui.renderer.Copy(ui.pTextureSheet, &ui.pSrc, &ui.pDest)
@@ -8,15 +8,12 @@ import (
 	"AirPygee/game"
 	"bufio"
 	"encoding/xml"
-	"fmt"
-	"github.com/veandco/go-sdl2/img"
 	"github.com/veandco/go-sdl2/mix"
 	"github.com/veandco/go-sdl2/sdl"
 	"github.com/veandco/go-sdl2/ttf"
 	"io"
+	"io/fs"
 	"math/rand"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -38,6 +35,8 @@ const (
 	UIMenu
 	UIStartMenu
 	UIStartMenuDifficulty
+	UILoadError
+	UIStartMenuClass
 	itemSizeRatio float64 = 0.15
 	tileSize      int32   = 32
 )
@@ -105,6 +104,8 @@ type Animation struct {
 
 type ui struct {
 	state               uiState
+	assets              fs.FS
+	streamedAssets      [][]byte
 	sounds              sounds
 	winWidth, winHeight int
 	renderer            *sdl.Renderer
@@ -119,6 +120,8 @@ type ui struct {
 
 	//player
 	pTextureSheet                                     *sdl.Texture
+	pClass                                            *game.ClassDef
+	pSheetX, pSheetY                                  int32
 	pAnimSheet                                        *sdl.Texture
 	pWidthTex, pHeightTex                             int32
 	pFromX, pFromY, pFramesX, pFramesY, pCurrentFrame int32
@@ -138,7 +141,12 @@ type ui struct {
 	textureIndexTiles, textureIndexMonsters, textureIndexItems, textureIndexAnims, textureIndexChests TextureIndex
 
 	//animations
-	animations       map[rune]*Animation
+	// animMu guards the animation layer, written by the animation goroutines and read while drawing
+	animMu     sync.Mutex
+	animations map[rune]*Animation
+	// tileAnims are the animations running on the tiles of animLevel, the level drawn last
+	tileAnims        map[game.Pos]rune
+	animLevel        string
 	currentAnim      *Animation
 	damagesToDisplay map[string]*Damage
 
@@ -146,6 +154,8 @@ type ui struct {
 	r                *rand.Rand
 	levelChan        chan *game.Level
 	inputChan        chan *game.Input
+	errorChan        chan error
+	loadError        error
 	offsetX, offsetY int32
 
 	// Inventory
@@ -169,17 +179,40 @@ type ui struct {
 	//Start Menu
 	startMenuButtons  []*menuButton
 	difficultyButtons []*menuButton
+	classButtons      []*menuButton
+	classes           []*game.ClassDef
+	// survival is the survival mode toggled from the difficulty menu
+	survival bool
+
+	// historyOpen shows the whole message log instead of the last events, scrolled back by historyScroll messages
+	historyOpen     bool
+	historyScroll   int
+	historyMessages []game.Message
+
+	// spectating windows only display a game played by something else, like a replay
+	spectating bool
+	// player is the ID of the hero the window plays, hot-seat windows pass it from hero to hero
+	player  int
+	hotSeat bool
 }
 
-func NewUI(inputChan chan *game.Input, levelChan chan *game.Level) *ui {
+// NewUI opens the game window, its textures, fonts and sounds are read from the assets directory of assets.
+// defs are the game definitions the hero classes are picked from, nil when they could not be read
+func NewUI(assets fs.FS, defs *game.Defs, inputChan chan *game.Input, levelChan chan *game.Level, errorChan chan error) *ui {
 	ui := &ui{}
+	ui.assets = assets
+	if defs != nil {
+		ui.classes = defs.Classes
+	}
 	ui.state = UIStartMenu
 	ui.inputChan = inputChan
 	ui.levelChan = levelChan
+	ui.errorChan = errorChan
 	ui.str2TexSmall.texs = make(map[coloredFont]*sdl.Texture)
 	ui.str2TexMedium.texs = make(map[coloredFont]*sdl.Texture)
 	ui.str2TexLarge.texs = make(map[coloredFont]*sdl.Texture)
 	ui.animations = make(map[rune]*Animation)
+	ui.tileAnims = make(map[game.Pos]rune)
 	ui.damagesToDisplay = make(map[string]*Damage)
 	ui.textureIndexChests.rects = make(map[rune][]*sdl.Rect)
 	ui.r = rand.New(rand.NewSource(1))
@@ -195,30 +228,27 @@ func NewUI(inputChan chan *game.Input, levelChan chan *game.Level) *ui {
 
 	sdl.SetHint(sdl.HINT_RENDER_SCALE_QUALITY, "0")
 
-	ui.textureAtlas = ui.imgFileToTexture("ui2d/assets/tiles.png")
-	ui.tileMap = ui.imgFileToTexture("ui2d/assets/tilemap.png")
-	ui.uipack = ui.imgFileToTexture("ui2d/assets/uipack_rpg_sheet.png")
-	ui.metalPlate = ui.imgFileToTexture("ui2d/assets/metal_plate_tex.png")
-
-	ui.loadTextureIndex(&ui.textureIndexTiles, "ui2d/assets/atlas-index.txt")
-	ui.loadTextureIndex(&ui.textureIndexMonsters, "ui2d/assets/atlas-index-monsters.txt")
-	ui.loadTextureIndex(&ui.textureIndexItems, "ui2d/assets/atlas-index-items.txt")
-	ui.loadTextureIndex(&ui.textureIndexAnims, "ui2d/assets/atlas-index-anims.txt")
-	ui.LoadPlayer()
+	ui.textureAtlas = ui.imgFileToTexture("assets/tiles.png")
+	ui.tileMap = ui.imgFileToTexture("assets/tilemap.png")
+	ui.uipack = ui.imgFileToTexture("assets/uipack_rpg_sheet.png")
+	ui.metalPlate = ui.imgFileToTexture("assets/metal_plate_tex.png")
+
+	ui.loadTextureIndex(&ui.textureIndexTiles, "assets/atlas-index.txt")
+	ui.textureIndexMonsters.rects = make(map[rune][]*sdl.Rect)
+	ui.loadTextureIndex(&ui.textureIndexItems, "assets/atlas-index-items.txt")
+	ui.loadTextureIndex(&ui.textureIndexAnims, "assets/atlas-index-anims.txt")
+	if len(ui.classes) > 0 {
+		ui.LoadPlayer(ui.classes[0])
+	}
 	ui.LoadPlayerAnims()
 	ui.loadSpritesheetFromXml()
 
 	ui.centerX = -1
 	ui.centerY = -1
 
-	ui.fontSmall, err = ttf.OpenFont("ui2d/assets/Kingthings_Foundation.ttf", int(float64(ui.winWidth)*0.010))
-	game.CheckError(err)
-
-	ui.fontMedium, err = ttf.OpenFont("ui2d/assets/Kingthings_Foundation.ttf", 24)
-	game.CheckError(err)
-
-	ui.fontLarge, err = ttf.OpenFont("ui2d/assets/Kingthings_Foundation.ttf", 32)
-	game.CheckError(err)
+	ui.fontSmall = ui.loadFont("assets/Kingthings_Foundation.ttf", int(float64(ui.winWidth)*0.010))
+	ui.fontMedium = ui.loadFont("assets/Kingthings_Foundation.ttf", 24)
+	ui.fontLarge = ui.loadFont("assets/Kingthings_Foundation.ttf", 32)
 
 	ui.musicVolume = 32
 	ui.soundsVolume = 10
@@ -267,39 +297,82 @@ func NewUI(inputChan chan *game.Input, levelChan chan *game.Level) *ui {
 	ui.buildMenuButtons()
 	ui.buildStartMenuButtons()
 	ui.buildDifficultyButtons()
+	ui.buildClassButtons()
 	ui.LoadTreasureChests()
 
 	return ui
 }
 
+// Spectate skips the start menu and stops sending player inputs to the game
+func (ui *ui) Spectate() {
+	ui.state = UIMain
+	ui.spectating = true
+}
+
+// HotSeat makes the window play every hero of the party in turn, Tab passing the seat to the next one.
+// Saved games have a single hero so there is nothing to continue
+func (ui *ui) HotSeat() {
+	ui.hotSeat = true
+	for i, button := range ui.startMenuButtons {
+		if button.name == "Continue" {
+			ui.startMenuButtons = append(ui.startMenuButtons[:i], ui.startMenuButtons[i+1:]...)
+			break
+		}
+	}
+}
+
+// send gives input to the game, played by the hero of the window
+func (ui *ui) send(input *game.Input) {
+	input.Player = ui.player
+	ui.inputChan <- input
+}
+
+// passSeat gives the seat of a hot-seat window to the hero after the current one in the party,
+// the first of them with the energy to act when ready is set
+func (ui *ui) passSeat(level *game.Level, ready bool) {
+	heroes := level.Players
+	for i := range heroes {
+		if heroes[i].ID != level.Player.ID {
+			continue
+		}
+		for j := 1; j < len(heroes); j++ {
+			next := heroes[(i+j)%len(heroes)]
+			if !ready || next.Ready() {
+				ui.player = next.ID
+				ui.send(&game.Input{Typ: game.Follow, LevelChannel: ui.levelChan})
+				return
+			}
+		}
+	}
+}
+
 func (ui *ui) loadSounds() {
 	err := mix.OpenAudio(22050, mix.DEFAULT_FORMAT, 2, 4096)
 	game.CheckError(err)
 
-	ui.music, err = mix.LoadMUS("ui2d/assets/audio/music/the_field_of_dreams.mp3")
-	game.CheckError(err)
+	ui.music = ui.loadMusic("assets/audio/music/the_field_of_dreams.mp3")
 
 	mix.VolumeMusic(ui.musicVolume)
 
 	err = ui.music.Play(-1)
 	game.CheckError(err)
 
-	ui.sounds.footstep = buildSoundsVariations("ui2d/assets/audio/sounds/Kenney/footstep*.ogg")
-	ui.sounds.openDoor = buildSoundsVariations("ui2d/assets/audio/sounds/Kenney/doorOpen*.ogg")
-	ui.sounds.closeDoor = buildSoundsVariations("ui2d/assets/audio/sounds/Kenney/doorClose*.ogg")
-	ui.sounds.swing = buildSoundsVariations("ui2d/assets/audio/sounds/battle/swing*.wav")
-	ui.sounds.pickup = buildSoundsVariations("ui2d/assets/audio/sounds/Kenney/cloth*.ogg")
-	ui.sounds.potion = buildSoundsVariations("ui2d/assets/audio/sounds/Kenney/bubble*.wav")
+	ui.sounds.footstep = ui.buildSoundsVariations("assets/audio/sounds/Kenney/footstep*.ogg")
+	ui.sounds.openDoor = ui.buildSoundsVariations("assets/audio/sounds/Kenney/doorOpen*.ogg")
+	ui.sounds.closeDoor = ui.buildSoundsVariations("assets/audio/sounds/Kenney/doorClose*.ogg")
+	ui.sounds.swing = ui.buildSoundsVariations("assets/audio/sounds/battle/swing*.wav")
+	ui.sounds.pickup = ui.buildSoundsVariations("assets/audio/sounds/Kenney/cloth*.ogg")
+	ui.sounds.potion = ui.buildSoundsVariations("assets/audio/sounds/Kenney/bubble*.wav")
 
 }
 
-func buildSoundsVariations(pattern string) []*mix.Chunk {
-	fileNames, err := filepath.Glob(pattern)
+func (ui *ui) buildSoundsVariations(pattern string) []*mix.Chunk {
+	fileNames, err := fs.Glob(ui.assets, pattern)
 	game.CheckError(err)
 	result := make([]*mix.Chunk, 0)
 
 	for _, fileName := range fileNames {
-		sound, err := mix.LoadWAV(fileName)
+		sound, err := mix.LoadWAVRW(ui.assetRW(fileName), true)
 		game.CheckError(err)
 
 		result = append(result, sound)
@@ -355,12 +428,7 @@ func (ui *ui) stringToTexture(s string, color sdl.Color, size FontSize) *sdl.Tex
 		ui.str2TexLarge.mu.RUnlock()
 	}
 
-	fontSurface, err := font.RenderUTF8Blended(s, color)
-	game.CheckError(err)
-	defer fontSurface.Free()
-
-	tex, err := ui.renderer.CreateTextureFromSurface(fontSurface)
-	game.CheckError(err)
+	tex := ui.renderString(font, s, color)
 
 	switch size {
 	case FontSmall:
@@ -380,11 +448,34 @@ func (ui *ui) stringToTexture(s string, color sdl.Color, size FontSize) *sdl.Tex
 	return tex
 }
 
+// renderString returns a new texture of s, the caller destroys it once drawn
+func (ui *ui) renderString(font *ttf.Font, s string, color sdl.Color) *sdl.Texture {
+	fontSurface, err := font.RenderUTF8Blended(s, color)
+	game.CheckError(err)
+	defer fontSurface.Free()
+
+	tex, err := ui.renderer.CreateTextureFromSurface(fontSurface)
+	game.CheckError(err)
+	return tex
+}
+
+// drawText draws s at x, y without caching its texture, for texts which are rarely drawn twice like the messages of the log
+func (ui *ui) drawText(s string, color sdl.Color, x, y int32) {
+	tex := ui.renderString(ui.fontSmall, s, color)
+	defer func() {
+		game.CheckError(tex.Destroy())
+	}()
+	_, _, w, h, err := tex.Query()
+	game.CheckError(err)
+	game.CheckError(ui.renderer.Copy(tex, nil, &sdl.Rect{X: x, Y: y, W: w, H: h}))
+}
+
 func (ui *ui) loadTextureIndex(textureIndex *TextureIndex, fileName string) {
 	textureIndex.rects = make(map[rune][]*sdl.Rect)
 
-	infile, err := os.Open(fileName)
+	infile, err := ui.assets.Open(fileName)
 	game.CheckError(err)
+	defer infile.Close()
 
 	scanner := bufio.NewScanner(infile)
 	for scanner.Scan() {
@@ -402,29 +493,28 @@ func (ui *ui) loadTextureIndex(textureIndex *TextureIndex, fileName string) {
 		variationCount, err := strconv.ParseInt(strings.TrimSpace(splitXyC[2]), 10, 64)
 		game.CheckError(err)
 
-		var rects []*sdl.Rect
-		for i := int64(0); i < variationCount; i++ {
-			rects = append(rects, &sdl.Rect{X: int32(x) * tileSize, Y: int32(y) * tileSize, W: tileSize, H: tileSize})
-			x++
-			if x > 62 {
-				x = 0
-				y++
-			}
-		}
-		textureIndex.rects[tileRune] = rects
+		textureIndex.rects[tileRune] = atlasRects(int(x), int(y), int(variationCount))
 	}
 
 }
 
-func (ui *ui) imgFileToTexture(filename string) *sdl.Texture {
-	infile, err := os.Open(filename)
-	game.CheckError(err)
-	defer infile.Close()
-
-	image, err := img.Load(filename)
-	if err != nil {
-		panic(err)
+// atlasRects returns the rects of variationCount tiles following each other in the atlas from x,y
+func atlasRects(x, y, variationCount int) []*sdl.Rect {
+	var rects []*sdl.Rect
+	for i := 0; i < variationCount; i++ {
+		rects = append(rects, &sdl.Rect{X: int32(x) * tileSize, Y: int32(y) * tileSize, W: tileSize, H: tileSize})
+		x++
+		if x > 62 {
+			x = 0
+			y++
+		}
 	}
+	return rects
+}
+
+func (ui *ui) imgFileToTexture(filename string) *sdl.Texture {
+	image := ui.loadImage(filename)
+	defer image.Free()
 
 	tex, err := ui.renderer.CreateTextureFromSurface(image)
 	game.CheckError(err)
@@ -464,7 +554,7 @@ type SubTexture struct {
 }
 
 func (ui *ui) loadSpritesheetFromXml() {
-	xmlFile, err := os.Open("ui2d/assets/uipack_rpg_sheet.xml")
+	xmlFile, err := ui.assets.Open("assets/uipack_rpg_sheet.xml")
 	game.CheckError(err)
 
 	defer xmlFile.Close()
@@ -476,11 +566,10 @@ func (ui *ui) loadSpritesheetFromXml() {
 }
 
 func (ui *ui) LoadTreasureChests() {
-	image, err := img.Load("ui2d/assets/chests.png")
-	game.CheckError(err)
-
+	image := ui.loadImage("assets/chests.png")
 	defer image.Free()
 
+	var err error
 	ui.chestsTex, err = ui.renderer.CreateTextureFromSurface(image)
 	game.CheckError(err)
 
@@ -539,6 +628,7 @@ func (ui *ui) draw(level *game.Level) {
 	err := ui.renderer.Clear()
 	game.CheckError(err)
 	ui.r.Seed(1)
+	ui.animMu.Lock()
 	for y, row := range level.Map {
 		for x, tile := range row {
 			if tile.Rune != game.Blank {
@@ -575,17 +665,19 @@ func (ui *ui) draw(level *game.Level) {
 					}
 
 					// display current running animation if any
-					if tile.AnimRune != game.Blank {
-						srcRect = ui.animations[tile.AnimRune].rect
-						//err = ui.renderer.CopyEx(ui.animations[tile.AnimRune].tex, srcRect, &dstRect, 0, nil, sdl.FLIP_HORIZONTAL)
-						err = ui.renderer.Copy(ui.animations[tile.AnimRune].tex, srcRect, &dstRect)
+					if anim, exists := ui.tileAnims[pos]; exists {
+						srcRect = ui.animations[anim].rect
+						//err = ui.renderer.CopyEx(ui.animations[anim].tex, srcRect, &dstRect, 0, nil, sdl.FLIP_HORIZONTAL)
+						err = ui.renderer.Copy(ui.animations[anim].tex, srcRect, &dstRect)
 						game.CheckError(err)
 					}
 				}
 			}
 		}
 	}
+	ui.animMu.Unlock()
 	ui.displayMonsters(level)
+	ui.displayHeroes(level)
 	ui.displayItems(level)
 	if !ui.pAnimated {
 		ui.drawPlayer(level)
@@ -605,9 +697,14 @@ func (ui *ui) draw(level *game.Level) {
 		}
 		game.CheckError(err)
 	}
+	ui.displayEffects(&level.Player.Character)
 	ui.displayHUD(level)
 	ui.displayStats(level)
-	ui.displayEvents(level)
+	if ui.historyOpen {
+		ui.displayHistory(level)
+	} else {
+		ui.displayEvents(level)
+	}
 	ui.displayDamages()
 
 	// display item we are on top of
@@ -664,63 +761,56 @@ func (ui *ui) pickupGroundItem(level *game.Level, mouseX, mouseY int32) game.Ite
 	return nil
 }
 
-func (ui *ui) fire(level *game.Level, attackRange int) {
-	var direction rune
-	var deltaX, deltaY int
-	firstPos := level.FrontOf()
-	positions := make([]game.Pos, 0)
-
-	fmt.Println("firstPos : ", firstPos)
-	fmt.Println("Player Pos : ", level.Player.Pos)
-	if firstPos == level.Player.Pos {
-		fmt.Println("positions are equal, switching to wantedTo")
-	}
-
-	switch {
-	case firstPos.X > level.Player.X:
-		direction = game.RightAnim
-		deltaX = 1
-	case firstPos.X < level.Player.X:
-		direction = game.LeftAnim
-		deltaX = -1
-	case firstPos.Y > level.Player.Y:
-		direction = game.DownAnim
-		deltaY = 1
-	case firstPos.Y < level.Player.Y:
-		direction = game.UpAnim
-		deltaY = -1
-	default:
-		//TODO - bug here when firstPos == level.Player.Pos == level.Player.Wanted
-		//a simple return is fixing the bug at this time
-		fmt.Println("Wanted To", level.Player.WantedTo)
-		return
-	}
-
-	for i := 1; i <= attackRange; i++ {
-		x := level.Player.X + deltaX*i
-		y := level.Player.Y + deltaY*i
+// moveKeys are the arrows, the numpad and the vi keys moving the player, diagonals only move on games allowing them
+var moveKeys = map[sdl.Keycode]game.InputType{
+	sdl.K_UP:    game.Up,
+	sdl.K_DOWN:  game.Down,
+	sdl.K_LEFT:  game.Left,
+	sdl.K_RIGHT: game.Right,
+	sdl.K_KP_8:  game.Up,
+	sdl.K_KP_2:  game.Down,
+	sdl.K_KP_4:  game.Left,
+	sdl.K_KP_6:  game.Right,
+	sdl.K_KP_7:  game.UpLeft,
+	sdl.K_KP_9:  game.UpRight,
+	sdl.K_KP_1:  game.DownLeft,
+	sdl.K_KP_3:  game.DownRight,
+	sdl.K_k:     game.Up,
+	sdl.K_j:     game.Down,
+	sdl.K_h:     game.Left,
+	sdl.K_l:     game.Right,
+	sdl.K_y:     game.UpLeft,
+	sdl.K_u:     game.UpRight,
+	sdl.K_b:     game.DownLeft,
+	sdl.K_n:     game.DownRight,
+}
 
-		if x < 0 || !level.Map[y][x].Walkable {
-			break
+// playEvent plays the sound and the animations of an event of the last turn
+func (ui *ui) playEvent(level *game.Level, event game.TurnEvent) {
+	switch event.Type {
+	case game.Move, game.LevelChanged:
+		if event.ActorID == level.Player.CharacterID() {
+			playRandomSound(ui.sounds.footstep, ui.soundsVolume)
 		}
-		if y < 0 || !level.Map[y][x].Walkable {
-			break
+	case game.DoorOpen:
+		playRandomSound(ui.sounds.openDoor, ui.soundsVolume)
+	case game.DoorClose:
+		playRandomSound(ui.sounds.closeDoor, ui.soundsVolume)
+	case game.Attack:
+		playRandomSound(ui.sounds.swing, ui.soundsVolume)
+		if event.ActorID == level.Player.CharacterID() && level.LastShot.Anim == game.Blank && !ui.pAnimated {
+			go ui.displayPlayerAnimation(3*time.Second, 100*time.Millisecond, 'c', &ui.pAnims, ui.pAnimSheet)
 		}
-		if mob, ok := level.Monsters[game.Pos{X: x, Y: y}]; ok {
-			level.Attack(&level.Player.Character, &mob.Character)
-			if mob.Health <= 0 {
-				mob.Kill(level)
-			}
-			if level.Player.Health <= 0 {
-				//game.Dead()
-			}
-			break
-		}
-
-		positions = append(positions, game.Pos{X: x, Y: y})
+		go ui.addAttackResult(event.Damage, 250*time.Millisecond, event.Critical, game.Pos{X: event.Pos.X, Y: event.Pos.Y - 1})
+	case game.Pickup:
+		playRandomSound(ui.sounds.pickup, ui.soundsVolume)
+	case game.ConsumePotion, game.Eat:
+		playRandomSound(ui.sounds.potion, ui.soundsVolume)
+	case game.OpenChest:
+		playRandomSound(ui.sounds.openDoor, ui.soundsVolume)
+	case game.SpellCast:
+		playRandomSound(ui.sounds.swing, ui.soundsVolume)
 	}
-
-	go ui.displayMovingAnimation(level, 250*time.Millisecond, 100*time.Millisecond, positions, direction, &ui.textureIndexAnims, ui.textureAtlas)
 }
 
 // Run main UI loop
@@ -735,28 +825,33 @@ func (ui *ui) Run() {
 		select {
 		case newLevel, ok = <-ui.levelChan:
 			if ok {
-				switch newLevel.LastEvent {
-				case game.Move:
-					playRandomSound(ui.sounds.footstep, ui.soundsVolume)
-				case game.DoorOpen:
-					playRandomSound(ui.sounds.openDoor, ui.soundsVolume)
-				case game.DoorClose:
-					playRandomSound(ui.sounds.closeDoor, ui.soundsVolume)
-				case game.Attack:
-					playRandomSound(ui.sounds.swing, ui.soundsVolume)
+				// the running tile animations belong to the level they started on
+				if newLevel.Name != ui.animLevel {
+					ui.animMu.Lock()
+					ui.tileAnims = make(map[game.Pos]rune)
+					ui.animLevel = newLevel.Name
+					ui.animMu.Unlock()
+				}
+				for _, event := range newLevel.TurnEvents {
+					ui.playEvent(newLevel, event)
+				}
+				if ui.hotSeat {
+					// the hero of the seat died, or is waiting for the others
+					ui.player = newLevel.Player.ID
+					if !newLevel.Player.Ready() {
+						ui.passSeat(newLevel, true)
+					}
+				}
+				// spells and shots are animated along their path
+				if newLevel.LastSpell.Name != "" {
+					go ui.displaySpellAnimation(newLevel.LastSpell)
+				}
+				if newLevel.LastShot.Anim != game.Blank {
 					if !ui.pAnimated {
-						go ui.displayPlayerAnimation(3*time.Second, 100*time.Millisecond, 'c', &ui.pAnims, ui.pAnimSheet)
+						go ui.displayPlayerAnimation(1*time.Second, 200*time.Millisecond, 'b', &ui.pAnims, ui.pAnimSheet)
 					}
-					go ui.addAttackResult(newLevel.LastAttack.Damage, 250*time.Millisecond, newLevel.LastAttack.IsCritical, game.Pos{X: newLevel.LastAttack.Who.X, Y: newLevel.LastAttack.Who.Y - 1})
-				case game.Pickup:
-					playRandomSound(ui.sounds.pickup, ui.soundsVolume)
-				case game.ConsumePotion:
-					playRandomSound(ui.sounds.potion, ui.soundsVolume)
-				case game.OpenChest:
-					playRandomSound(ui.sounds.openDoor, ui.soundsVolume)
-				default:
+					go ui.displayMovingAnimation(250*time.Millisecond, 100*time.Millisecond, newLevel.LastShot.Path, newLevel.LastShot.Anim, &ui.textureIndexAnims, ui.textureAtlas)
 				}
-				newLevel.LastEvent = game.Empty
 				if ui.state == UIMain {
 					ui.draw(newLevel)
 				} else if ui.state == UIInventory {
@@ -764,63 +859,58 @@ func (ui *ui) Run() {
 					ui.drawInventory(newLevel)
 				}
 			}
+		case err := <-ui.errorChan:
+			ui.loadError = err
+			ui.state = UILoadError
 		default:
 		}
 		if ui.state == UIStartMenu {
 			ui.startMenuActions()
 		}
-		ui.draw(newLevel)
+		if ui.state == UILoadError {
+			ui.loadErrorActions()
+		}
+		if newLevel != nil {
+			ui.draw(newLevel)
+		}
 
 		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
 			switch e := event.(type) {
 			case *sdl.QuitEvent:
-				ui.inputChan <- &game.Input{Typ: game.QuitGame}
+				ui.send(&game.Input{Typ: game.QuitGame})
 			case *sdl.WindowEvent:
 				if e.Event == sdl.WINDOWEVENT_CLOSE {
-					ui.inputChan <- &game.Input{Typ: game.CloseWindow, LevelChannel: ui.levelChan}
+					ui.send(&game.Input{Typ: game.CloseWindow, LevelChannel: ui.levelChan})
 				}
 			case *sdl.MouseButtonEvent:
-				if e.State == sdl.RELEASED && e.Button == sdl.BUTTON_LEFT {
+				if e.State == sdl.RELEASED && e.Button == sdl.BUTTON_LEFT && !ui.spectating {
 					//if clicked on ground item zone
 					item := ui.pickupGroundItem(newLevel, e.X, e.Y)
 					if item != nil {
-						ui.inputChan <- &game.Input{Typ: game.TakeItem, Item: item}
+						ui.send(&game.Input{Typ: game.TakeItem, Item: item})
 					}
 				}
+			case *sdl.MouseWheelEvent:
+				ui.scrollHistory(int(e.Y) * historyScrollLines)
 			case *sdl.KeyboardEvent:
-				if e.State != sdl.PRESSED {
+				// the history can be read while spectating too
+				if e.State == sdl.PRESSED && ui.historyActions(e.Keysym.Sym) {
+					break
+				}
+				if e.State != sdl.PRESSED || ui.spectating {
 					break
 				}
 				switch e.Keysym.Sym {
 				case sdl.K_a:
-					for _, item := range newLevel.Player.EquippedItems {
-						if item.GetName() == "Bow" {
-							if !ui.pAnimated {
-								go ui.displayPlayerAnimation(1*time.Second, 200*time.Millisecond, 'b', &ui.pAnims, ui.pAnimSheet)
-								ui.fire(newLevel, 3)
-							}
-						}
-					}
-					//pos := []game.Pos{{3, 2}}
-					//go ui.displayMovingAnimation(newLevel, 5*time.Second, pos, game.AnimatedPortal, &ui.textureIndexAnims, ui.textureAtlas)
+					input = game.Input{Typ: game.Fire}
+				case sdl.K_1, sdl.K_2, sdl.K_3, sdl.K_4:
+					input = game.Input{Typ: game.CastSpell, Spell: int(e.Keysym.Sym - sdl.K_1)}
 				case sdl.K_ESCAPE:
 					if ui.state == UIMain {
 						ui.state = UIMenu
 						ui.menuActions()
 					}
 					ui.state = UIMain
-				case sdl.K_UP:
-					input = game.Input{Typ: game.Up}
-					ui.UpdatePlayer(game.Up)
-				case sdl.K_DOWN:
-					input = game.Input{Typ: game.Down}
-					ui.UpdatePlayer(game.Down)
-				case sdl.K_LEFT:
-					input = game.Input{Typ: game.Left}
-					ui.UpdatePlayer(game.Left)
-				case sdl.K_RIGHT:
-					input = game.Input{Typ: game.Right}
-					ui.UpdatePlayer(game.Right)
 				case sdl.K_e:
 					pos := newLevel.FrontOf()
 					if newLevel.Items[pos] != nil {
@@ -828,7 +918,7 @@ func (ui *ui) Run() {
 						case game.OpenableItem:
 							if newLevel.Map[pos.Y][pos.X].Actionable {
 								input = game.Input{Typ: game.Action, Item: newLevel.Items[pos][0]}
-								go ui.displayTileAnimation(newLevel, 0, 250*time.Millisecond, pos, rune(newLevel.Items[pos][0].(game.OpenableItem).GetSize()), &ui.textureIndexChests, ui.chestsTex)
+								go ui.displayTileAnimation(0, 250*time.Millisecond, pos, rune(newLevel.Items[pos][0].(game.OpenableItem).GetSize()), &ui.textureIndexChests, ui.chestsTex)
 							}
 						default:
 							input = game.Input{Typ: game.Action}
@@ -838,6 +928,12 @@ func (ui *ui) Run() {
 					}
 				case sdl.K_t:
 					input = game.Input{Typ: game.TakeAll}
+				case sdl.K_f:
+					input = game.Input{Typ: game.UseAbility}
+				case sdl.K_TAB:
+					if ui.hotSeat {
+						ui.passSeat(newLevel, false)
+					}
 				case sdl.K_i:
 					if ui.state == UIMain {
 						ui.state = UIInventory
@@ -846,9 +942,13 @@ func (ui *ui) Run() {
 					ui.state = UIMain
 				default:
 					input = game.Input{Typ: game.None}
+					if move, exists := moveKeys[e.Keysym.Sym]; exists {
+						input = game.Input{Typ: move}
+						ui.UpdatePlayer(move)
+					}
 				}
 				if input.Typ != game.None {
-					ui.inputChan <- &input
+					ui.send(&input)
 				}
 			}
 		}
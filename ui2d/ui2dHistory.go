@@ -0,0 +1,76 @@
+package ui2d
+
+import (
+	"AirPygee/game"
+	"fmt"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// historyScrollLines is the number of messages scrolled by a page key or a notch of the mouse wheel
+const historyScrollLines = 3
+
+// historyActions handles the keys of the message history, it returns true when key was used by it
+func (ui *ui) historyActions(key sdl.Keycode) bool {
+	switch key {
+	case sdl.K_m:
+		ui.historyOpen = !ui.historyOpen
+		ui.historyScroll = 0
+	case sdl.K_PAGEUP:
+		ui.scrollHistory(historyScrollLines)
+	case sdl.K_PAGEDOWN:
+		ui.scrollHistory(-historyScrollLines)
+	case sdl.K_HOME:
+		ui.scrollHistory(len(ui.historyMessages))
+	case sdl.K_END:
+		ui.historyScroll = 0
+	default:
+		return false
+	}
+	return ui.historyOpen || key == sdl.K_m
+}
+
+// scrollHistory moves the history back in time by lines messages, forward when lines is negative
+func (ui *ui) scrollHistory(lines int) {
+	if !ui.historyOpen {
+		return
+	}
+	ui.historyScroll += lines
+	if ui.historyScroll > len(ui.historyMessages)-1 {
+		ui.historyScroll = len(ui.historyMessages) - 1
+	}
+	if ui.historyScroll < 0 {
+		ui.historyScroll = 0
+	}
+}
+
+// displayHistory draws the whole message log of the run in place of the event list, scrolled back by historyScroll messages
+func (ui *ui) displayHistory(level *game.Level) {
+	ui.historyMessages = level.Log.Messages
+	textStartX := int32(float64(ui.winWidth) * .015)
+	textStartY := int32(float64(ui.winHeight) * .3)
+	textWidth := int32(float64(ui.winWidth) * .4)
+	_, fontSizeY, _ := ui.fontSmall.SizeUTF8("A")
+
+	err := ui.renderer.Copy(ui.uipack, ui.getRectFromTextureName("panel_beige.png"), &sdl.Rect{X: 0, Y: textStartY - int32(fontSizeY), W: textWidth, H: int32(ui.winHeight) - textStartY + int32(fontSizeY)})
+	game.CheckError(err)
+
+	lines := (ui.winHeight-int(textStartY))/fontSizeY - 1
+	end := len(ui.historyMessages) - ui.historyScroll
+	start := end - lines
+	if start < 0 {
+		start = 0
+	}
+
+	title := fmt.Sprintf("Messages %d-%d of %d  (PgUp/PgDn, M to close)", start+1, end, len(ui.historyMessages))
+	ui.drawText(title, sdl.Color{R: 60, G: 30, A: 255}, textStartX, textStartY-int32(fontSizeY)/2)
+
+	for i, message := range ui.historyMessages[start:end] {
+		ui.drawText(fmt.Sprintf("%4d  %s", message.Turn, message.Text), messageColor(message), textStartX, textStartY+int32((i+1)*fontSizeY))
+	}
+}
+
+// messageColor is the colour a message is drawn with, following the hint of the game
+func messageColor(message game.Message) sdl.Color {
+	color := message.Color()
+	return sdl.Color{R: color.R, G: color.G, B: color.B, A: 255}
+}
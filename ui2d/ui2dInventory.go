@@ -19,17 +19,17 @@ func (ui *ui) menuInventory(level *game.Level) {
 			ui.drawInventory(level)
 			switch e := event.(type) {
 			case *sdl.QuitEvent:
-				ui.inputChan <- &game.Input{Typ: game.QuitGame}
+				ui.send(&game.Input{Typ: game.QuitGame})
 			case *sdl.WindowEvent:
 				if e.Event == sdl.WINDOWEVENT_CLOSE {
-					ui.inputChan <- &game.Input{Typ: game.CloseWindow, LevelChannel: ui.levelChan}
+					ui.send(&game.Input{Typ: game.CloseWindow, LevelChannel: ui.levelChan})
 				}
 			case *sdl.MouseButtonEvent:
 				if e.State == sdl.RELEASED && e.Button == sdl.BUTTON_LEFT {
 					//if clicked on ground item zone
 					item := ui.pickupGroundItem(level, e.X, e.Y)
 					if item != nil {
-						ui.inputChan <- &game.Input{Typ: game.TakeItem, Item: item}
+						ui.send(&game.Input{Typ: game.TakeItem, Item: item})
 					}
 				}
 				if e.State == sdl.PRESSED && e.Button == sdl.BUTTON_LEFT {
@@ -48,7 +48,7 @@ func (ui *ui) menuInventory(level *game.Level) {
 							if ui.hasClickedOnValidEquipSlot(e.X, e.Y, ui.draggedItem) && ui.isSlotFree(level, ui.draggedItem) {
 								item = ui.draggedItem
 							} else if ui.hasClickedOutsideInventoryZone(e.X, e.Y) {
-								ui.inputChan <- &game.Input{Typ: game.Drop, Item: ui.draggedItem}
+								ui.send(&game.Input{Typ: game.Drop, Item: ui.draggedItem})
 							}
 						}
 						if ui.dragMode == fromEquippedItems {
@@ -58,7 +58,7 @@ func (ui *ui) menuInventory(level *game.Level) {
 						}
 
 						if item != nil {
-							ui.inputChan <- &game.Input{Typ: game.Equip, Item: ui.draggedItem}
+							ui.send(&game.Input{Typ: game.Equip, Item: ui.draggedItem})
 						}
 						ui.draggedItem = nil
 						ui.dragMode = none
@@ -70,10 +70,10 @@ func (ui *ui) menuInventory(level *game.Level) {
 					item := ui.clickValidItem(level, e.X, e.Y)
 					if item != nil {
 						switch item.GetEntity().Type {
-						case game.Potions:
-							ui.inputChan <- &game.Input{Typ: game.Action, Item: item}
+						case game.Potions, game.Foods:
+							ui.send(&game.Input{Typ: game.Action, Item: item})
 						case game.Weapons, game.Armors:
-							ui.inputChan <- &game.Input{Typ: game.Equip, Item: item}
+							ui.send(&game.Input{Typ: game.Equip, Item: item})
 						}
 					}
 				}
@@ -91,7 +91,7 @@ func (ui *ui) menuInventory(level *game.Level) {
 				}
 				switch e.Keysym.Sym {
 				case sdl.K_t:
-					ui.inputChan <- &game.Input{Typ: game.TakeAll}
+					ui.send(&game.Input{Typ: game.TakeAll})
 				case sdl.K_ESCAPE, sdl.K_i:
 					ui.state = UIMain
 					return
@@ -107,7 +107,7 @@ func (ui *ui) menuInventory(level *game.Level) {
 func (ui *ui) drawInventory(level *game.Level) {
 	var locationX, locationY int32
 
-	playerSrcRect := sdl.Rect{X: 0, Y: 0, W: 26, H: 36}
+	playerSrcRect := sdl.Rect{X: ui.pSheetX, Y: ui.pSheetY, W: ui.pWidthTex, H: ui.pHeightTex}
 	playerX := ((ui.invWidth - (ui.invWidth / 3)) / 2) + ui.invOffsetX
 	playerY := ((ui.invHeight - (ui.invHeight / 3)) / 2) + ui.invOffsetY
 
@@ -197,7 +197,7 @@ func (ui *ui) drawInventory(level *game.Level) {
 		} else {
 			var size int32
 			size = ui.itemW
-			if item.GetName() == "Potion" {
+			if item.GetEntity().Type == game.Potions {
 				switch item.(game.ConsumableItem).GetSize() {
 				case "Small":
 					size = int32(float64(size) * .50)
@@ -0,0 +1,61 @@
+package ui2d
+
+import (
+	"AirPygee/game"
+	"github.com/veandco/go-sdl2/sdl"
+	"os"
+	"strings"
+)
+
+// loadErrorActions shows why the levels could not be loaded until the window is closed
+func (ui *ui) loadErrorActions() {
+	ui.displayLoadError()
+	for ui.state == UILoadError {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			switch e := event.(type) {
+			case *sdl.QuitEvent:
+				os.Exit(1)
+			case *sdl.WindowEvent:
+				if e.Event == sdl.WINDOWEVENT_CLOSE {
+					os.Exit(1)
+				}
+			case *sdl.KeyboardEvent:
+				if e.State != sdl.PRESSED {
+					break
+				}
+				switch e.Keysym.Sym {
+				case sdl.K_ESCAPE, sdl.K_RETURN:
+					os.Exit(1)
+				}
+			}
+		}
+		sdl.Delay(5)
+	}
+}
+
+func (ui *ui) displayLoadError() {
+	err := ui.renderer.Clear()
+	game.CheckError(err)
+
+	err = ui.renderer.Copy(ui.uipack, ui.getRectFromTextureName("panel_beige.png"), &sdl.Rect{X: ui.invOffsetX, Y: ui.invOffsetY, W: ui.invWidth, H: ui.invHeight})
+	game.CheckError(err)
+
+	lines := append([]string{"Could not load the game:", ""}, strings.Split(ui.loadError.Error(), "\n")...)
+	lines = append(lines, "", "Press Escape to quit")
+
+	y := ui.invOffsetY + int32(float64(ui.invHeight)*.05)
+	for _, line := range lines {
+		if line == "" {
+			_, h, _ := ui.fontSmall.SizeUTF8("A")
+			y += int32(h)
+			continue
+		}
+		tex := ui.stringToTexture(line, sdl.Color{R: 139, G: 69, B: 19}, FontSmall)
+		_, _, w, h, _ := tex.Query()
+		err = ui.renderer.Copy(tex, nil, &sdl.Rect{X: ui.invOffsetX + int32(float64(ui.invWidth)*.05), Y: y, W: w, H: h})
+		game.CheckError(err)
+		y += h
+	}
+
+	ui.renderer.Present()
+}
@@ -78,10 +78,10 @@ func (ui *ui) menuActions() {
 		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
 			switch e := event.(type) {
 			case *sdl.QuitEvent:
-				ui.inputChan <- &game.Input{Typ: game.QuitGame}
+				ui.send(&game.Input{Typ: game.QuitGame})
 			case *sdl.WindowEvent:
 				if e.Event == sdl.WINDOWEVENT_CLOSE {
-					ui.inputChan <- &game.Input{Typ: game.CloseWindow, LevelChannel: ui.levelChan}
+					ui.send(&game.Input{Typ: game.CloseWindow, LevelChannel: ui.levelChan})
 				}
 			case *sdl.KeyboardEvent:
 				if e.State != sdl.PRESSED {
@@ -188,11 +188,11 @@ func (ui *ui) doMenuAction() {
 			ui.soundsVolume = 10
 		}
 	case "Restart game":
-		ui.inputChan <- &game.Input{Typ: game.Restart, LevelChannel: ui.levelChan}
+		ui.send(&game.Input{Typ: game.Restart, LevelChannel: ui.levelChan})
 		ui.state = UIMain
 	case "Continue":
 		ui.state = UIMain
 	case "Quit":
-		ui.inputChan <- &game.Input{Typ: game.CloseWindow, LevelChannel: ui.levelChan}
+		ui.send(&game.Input{Typ: game.CloseWindow, LevelChannel: ui.levelChan})
 	}
 }
@@ -4,8 +4,6 @@ package ui2d
 
 import (
 	"AirPygee/game"
-	"github.com/veandco/go-sdl2/img"
-	"github.com/veandco/go-sdl2/mix"
 	"github.com/veandco/go-sdl2/sdl"
 )
 
@@ -20,9 +18,9 @@ func (ui *ui) buildDifficultyButtons() {
 
 	ui.difficultyButtons = append(ui.difficultyButtons, &menuButton{
 		name:           "Easy",
-		buttonRect:     &sdl.Rect{X: ui.invOffsetX + button.W/2, Y: ui.invOffsetY + button.H*8, W: button.W, H: button.H},
+		buttonRect:     &sdl.Rect{X: ui.invOffsetX + button.W/2, Y: ui.invOffsetY + button.H*12, W: button.W, H: button.H},
 		buttonTexture:  tex,
-		buttonTextRect: &sdl.Rect{X: ui.invOffsetX + button.W/2 + (button.W/2 - w/2), Y: ui.invOffsetY + button.H*8 + (button.H / 2) - (h / 2), W: w, H: h},
+		buttonTextRect: &sdl.Rect{X: ui.invOffsetX + button.W/2 + (button.W/2 - w/2), Y: ui.invOffsetY + button.H*12 + (button.H / 2) - (h / 2), W: w, H: h},
 		highlighted:    true,
 	})
 
@@ -32,9 +30,9 @@ func (ui *ui) buildDifficultyButtons() {
 
 	ui.difficultyButtons = append(ui.difficultyButtons, &menuButton{
 		name:           "Medium",
-		buttonRect:     &sdl.Rect{X: ui.invOffsetX + ui.invWidth/2 - button.W/2, Y: ui.invOffsetY + button.H*8, W: button.W, H: button.H},
+		buttonRect:     &sdl.Rect{X: ui.invOffsetX + ui.invWidth/2 - button.W/2, Y: ui.invOffsetY + button.H*12, W: button.W, H: button.H},
 		buttonTexture:  tex,
-		buttonTextRect: &sdl.Rect{X: ui.invOffsetX + ui.invWidth/2 - w/2, Y: ui.invOffsetY + button.H*8 + (button.H / 2) - (h / 2), W: w, H: h},
+		buttonTextRect: &sdl.Rect{X: ui.invOffsetX + ui.invWidth/2 - w/2, Y: ui.invOffsetY + button.H*12 + (button.H / 2) - (h / 2), W: w, H: h},
 		highlighted:    false,
 	})
 
@@ -44,13 +42,34 @@ func (ui *ui) buildDifficultyButtons() {
 
 	ui.difficultyButtons = append(ui.difficultyButtons, &menuButton{
 		name:           "Hard",
-		buttonRect:     &sdl.Rect{X: ui.invOffsetX + ui.invWidth - button.W - button.W/2, Y: ui.invOffsetY + button.H*8, W: button.W, H: button.H},
+		buttonRect:     &sdl.Rect{X: ui.invOffsetX + ui.invWidth - button.W - button.W/2, Y: ui.invOffsetY + button.H*12, W: button.W, H: button.H},
 		buttonTexture:  tex,
-		buttonTextRect: &sdl.Rect{X: ui.invOffsetX + ui.invWidth - button.W - button.W/2 + (button.W/2 - w/2), Y: ui.invOffsetY + button.H*8 + (button.H / 2) - (h / 2), W: w, H: h},
+		buttonTextRect: &sdl.Rect{X: ui.invOffsetX + ui.invWidth - button.W - button.W/2 + (button.W/2 - w/2), Y: ui.invOffsetY + button.H*12 + (button.H / 2) - (h / 2), W: w, H: h},
 		highlighted:    false,
 	})
 }
 
+// buildClassButtons spreads one button per hero class under the Class button
+func (ui *ui) buildClassButtons() {
+	button := ui.getRectFromTextureName("buttonLong_brown.png")
+	button.W /= 2
+	button.H /= 2
+
+	for i, class := range ui.classes {
+		tex := ui.stringToTexture(class.Name, sdl.Color{R: 139, G: 69, B: 19}, FontMedium)
+		_, _, w, h, _ := tex.Query()
+
+		x := ui.invOffsetX + int32(i+1)*ui.invWidth/int32(len(ui.classes)+1) - button.W/2
+		ui.classButtons = append(ui.classButtons, &menuButton{
+			name:           class.Name,
+			buttonRect:     &sdl.Rect{X: x, Y: ui.invOffsetY + button.H*16, W: button.W, H: button.H},
+			buttonTexture:  tex,
+			buttonTextRect: &sdl.Rect{X: x + (button.W/2 - w/2), Y: ui.invOffsetY + button.H*16 + (button.H / 2) - (h / 2), W: w, H: h},
+			highlighted:    i == 0,
+		})
+	}
+}
+
 func (ui *ui) buildStartMenuButtons() {
 	button := ui.getRectFromTextureName("buttonLong_brown.png")
 
@@ -66,15 +85,39 @@ func (ui *ui) buildStartMenuButtons() {
 		highlighted:    true,
 	})
 
+	// Continue button, only when there is a saved game to continue
+	if game.HasSave() {
+		tex = ui.stringToTexture("Continue", sdl.Color{R: 139, G: 69, B: 19}, FontMedium)
+		_, _, w, h, _ = tex.Query()
+
+		ui.startMenuButtons = append(ui.startMenuButtons, &menuButton{
+			name:           "Continue",
+			buttonRect:     &sdl.Rect{X: ui.invOffsetX + ui.invWidth/2 - button.W/2, Y: ui.invOffsetY + button.H*3, W: button.W, H: button.H},
+			buttonTexture:  tex,
+			buttonTextRect: &sdl.Rect{X: ui.invOffsetX + ui.invWidth/2 - w/2, Y: ui.invOffsetY + button.H*3 + (button.H / 2) - (h / 2), W: w, H: h},
+		})
+	}
+
 	// Difficulty button
 	tex = ui.stringToTexture("Difficulty", sdl.Color{R: 139, G: 69, B: 19}, FontMedium)
 	_, _, w, h, _ = tex.Query()
 
 	ui.startMenuButtons = append(ui.startMenuButtons, &menuButton{
 		name:           "Difficulty",
-		buttonRect:     &sdl.Rect{X: ui.invOffsetX + ui.invWidth/2 - button.W/2, Y: ui.invOffsetY + button.H*3, W: button.W, H: button.H},
+		buttonRect:     &sdl.Rect{X: ui.invOffsetX + ui.invWidth/2 - button.W/2, Y: ui.invOffsetY + button.H*5, W: button.W, H: button.H},
+		buttonTexture:  tex,
+		buttonTextRect: &sdl.Rect{X: ui.invOffsetX + ui.invWidth/2 - w/2, Y: ui.invOffsetY + button.H*5 + (button.H / 2) - (h / 2), W: w, H: h},
+	})
+
+	// Class button
+	tex = ui.stringToTexture("Class", sdl.Color{R: 139, G: 69, B: 19}, FontMedium)
+	_, _, w, h, _ = tex.Query()
+
+	ui.startMenuButtons = append(ui.startMenuButtons, &menuButton{
+		name:           "Class",
+		buttonRect:     &sdl.Rect{X: ui.invOffsetX + ui.invWidth/2 - button.W/2, Y: ui.invOffsetY + button.H*7, W: button.W, H: button.H},
 		buttonTexture:  tex,
-		buttonTextRect: &sdl.Rect{X: ui.invOffsetX + ui.invWidth/2 - w/2, Y: ui.invOffsetY + button.H*3 + (button.H / 2) - (h / 2), W: w, H: h},
+		buttonTextRect: &sdl.Rect{X: ui.invOffsetX + ui.invWidth/2 - w/2, Y: ui.invOffsetY + button.H*7 + (button.H / 2) - (h / 2), W: w, H: h},
 	})
 
 	// Quit button
@@ -84,23 +127,30 @@ func (ui *ui) buildStartMenuButtons() {
 
 	ui.startMenuButtons = append(ui.startMenuButtons, &menuButton{
 		name:           "Quit",
-		buttonRect:     &sdl.Rect{X: ui.invOffsetX + ui.invWidth/2 - button.W/2, Y: ui.invOffsetY + button.H*5, W: button.W, H: button.H},
+		buttonRect:     &sdl.Rect{X: ui.invOffsetX + ui.invWidth/2 - button.W/2, Y: ui.invOffsetY + button.H*9, W: button.W, H: button.H},
 		buttonTexture:  tex,
-		buttonTextRect: &sdl.Rect{X: ui.invOffsetX + ui.invWidth/2 - w/2, Y: ui.invOffsetY + button.H*5 + (button.H / 2) - (h / 2), W: w, H: h},
+		buttonTextRect: &sdl.Rect{X: ui.invOffsetX + ui.invWidth/2 - w/2, Y: ui.invOffsetY + button.H*9 + (button.H / 2) - (h / 2), W: w, H: h},
 	})
 
 }
 
 func (ui *ui) startMenuActions() {
 	ui.displayStartMenu()
-	for ui.state == UIStartMenu || ui.state == UIStartMenuDifficulty {
+	for ui.state == UIStartMenu || ui.state == UIStartMenuDifficulty || ui.state == UIStartMenuClass {
+		select {
+		case err := <-ui.errorChan:
+			ui.loadError = err
+			ui.state = UILoadError
+			return
+		default:
+		}
 		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
 			switch e := event.(type) {
 			case *sdl.QuitEvent:
-				ui.inputChan <- &game.Input{Typ: game.QuitGame}
+				ui.send(&game.Input{Typ: game.QuitGame})
 			case *sdl.WindowEvent:
 				if e.Event == sdl.WINDOWEVENT_CLOSE {
-					ui.inputChan <- &game.Input{Typ: game.CloseWindow, LevelChannel: ui.levelChan}
+					ui.send(&game.Input{Typ: game.CloseWindow, LevelChannel: ui.levelChan})
 				}
 			case *sdl.KeyboardEvent:
 				if e.State != sdl.PRESSED {
@@ -118,6 +168,23 @@ func (ui *ui) startMenuActions() {
 					case sdl.K_RIGHT:
 						ui.highlightRightDifficulty()
 						ui.displayDifficulty()
+					case sdl.K_s:
+						ui.survival = !ui.survival
+						ui.send(&game.Input{Typ: game.SetSurvival, Survival: ui.survival})
+						ui.displayDifficulty()
+					}
+				} else if ui.state == UIStartMenuClass {
+					switch e.Keysym.Sym {
+					case sdl.K_RETURN:
+						ui.doClassMenuAction()
+						ui.state = UIStartMenu
+						ui.displayStartMenu()
+					case sdl.K_LEFT:
+						ui.highlightLeftClass()
+						ui.displayClasses()
+					case sdl.K_RIGHT:
+						ui.highlightRightClass()
+						ui.displayClasses()
 					}
 				} else if ui.state == UIStartMenu {
 					switch e.Keysym.Sym {
@@ -167,9 +234,7 @@ func (ui *ui) highlightRightDifficulty() {
 }
 
 func (ui *ui) displayStartMenu() {
-	image, err := img.Load("ui2d/assets/startMenuBackground.jpeg")
-	game.CheckError(err)
-
+	image := ui.loadImage("assets/startMenuBackground.jpeg")
 	defer image.Free()
 
 	menuTex, err := ui.renderer.CreateTextureFromSurface(image)
@@ -197,15 +262,103 @@ func (ui *ui) displayStartMenu() {
 		game.CheckError(err)
 	}
 
-	tex := ui.stringToTexture(ui.getDifficultyHighlightedButton().name, sdl.Color{R: 139, G: 69, B: 19}, FontMedium)
+	difficulty := ui.getDifficultyHighlightedButton().name
+	if ui.survival {
+		difficulty += ", survival"
+	}
+	tex := ui.stringToTexture(difficulty, sdl.Color{R: 139, G: 69, B: 19}, FontMedium)
 	_, _, w, h, _ := tex.Query()
 
-	err = ui.renderer.Copy(tex, nil, &sdl.Rect{X: ui.invOffsetX + ui.invWidth/2 + buttonStandard.W/2, Y: ui.invOffsetY + buttonStandard.H*3 + (buttonStandard.H / 2) - (h / 2), W: w, H: h})
+	err = ui.renderer.Copy(tex, nil, &sdl.Rect{X: ui.invOffsetX + ui.invWidth/2 + buttonStandard.W/2, Y: ui.invOffsetY + buttonStandard.H*5 + (buttonStandard.H / 2) - (h / 2), W: w, H: h})
 	game.CheckError(err)
+
+	if class := ui.getClassHighlightedButton(); class != nil {
+		tex = ui.stringToTexture(class.name, sdl.Color{R: 139, G: 69, B: 19}, FontMedium)
+		_, _, w, h, _ = tex.Query()
+
+		err = ui.renderer.Copy(tex, nil, &sdl.Rect{X: ui.invOffsetX + ui.invWidth/2 + buttonStandard.W/2, Y: ui.invOffsetY + buttonStandard.H*7 + (buttonStandard.H / 2) - (h / 2), W: w, H: h})
+		game.CheckError(err)
+	}
 	ui.renderer.Present()
 
 }
 
+func (ui *ui) highlightLeftClass() {
+	for i, b := range ui.classButtons {
+		if b.highlighted {
+			b.highlighted = false
+			if i-1 < 0 {
+				ui.classButtons[len(ui.classButtons)-1].highlighted = true
+			} else {
+				ui.classButtons[i-1].highlighted = true
+			}
+			return
+		}
+	}
+}
+
+func (ui *ui) highlightRightClass() {
+	for i, b := range ui.classButtons {
+		if b.highlighted {
+			b.highlighted = false
+			if i+1 == len(ui.classButtons) {
+				ui.classButtons[0].highlighted = true
+			} else {
+				ui.classButtons[i+1].highlighted = true
+			}
+			return
+		}
+	}
+}
+
+func (ui *ui) getClassHighlightedButton() *menuButton {
+	for _, b := range ui.classButtons {
+		if b.highlighted {
+			return b
+		}
+	}
+	return nil
+}
+
+// displayClasses shows the class buttons and the description of the highlighted class
+func (ui *ui) displayClasses() {
+	buttonStandard := ui.getRectFromTextureName("buttonLong_brown.png")
+	buttonHighlighted := ui.getRectFromTextureName("buttonLong_grey.png")
+
+	for _, b := range ui.classButtons {
+		var button *sdl.Rect
+		if b.highlighted {
+			button = buttonHighlighted
+		} else {
+			button = buttonStandard
+		}
+		err := ui.renderer.Copy(ui.uipack, button, b.buttonRect)
+		game.CheckError(err)
+		err = ui.renderer.Copy(b.buttonTexture, nil, b.buttonTextRect)
+		game.CheckError(err)
+	}
+
+	highlighted := ui.getClassHighlightedButton()
+	for _, class := range ui.classes {
+		if class.Name != highlighted.name {
+			continue
+		}
+		err := ui.renderer.Copy(ui.uipack, ui.getRectFromTextureName("panel_beige.png"), &sdl.Rect{X: ui.invOffsetX, Y: ui.invOffsetY + buttonStandard.H*9, W: ui.invWidth, H: buttonStandard.H / 2})
+		game.CheckError(err)
+		tex := ui.stringToTexture(class.Description, sdl.Color{R: 139, G: 69, B: 19}, FontSmall)
+		_, _, w, h, _ := tex.Query()
+		err = ui.renderer.Copy(tex, nil, &sdl.Rect{X: ui.invOffsetX + ui.invWidth/2 - w/2, Y: ui.invOffsetY + buttonStandard.H*9 + buttonStandard.H/4 - h/2, W: w, H: h})
+		game.CheckError(err)
+	}
+
+	ui.renderer.Present()
+}
+
+func (ui *ui) doClassMenuAction() {
+	button := ui.getClassHighlightedButton()
+	ui.send(&game.Input{Typ: game.SelectClass, Class: button.name})
+}
+
 func (ui *ui) highlightPreviousStartMenu() {
 	for i, b := range ui.startMenuButtons {
 		if b.highlighted {
@@ -258,6 +411,21 @@ func (ui *ui) displayDifficulty() {
 	buttonStandard := ui.getRectFromTextureName("buttonLong_brown.png")
 	buttonHighlighted := ui.getRectFromTextureName("buttonLong_grey.png")
 
+	// survival toggle, under the difficulty buttons
+	survival := "Survival off (S)"
+	toggle := buttonStandard
+	if ui.survival {
+		survival = "Survival on (S)"
+		toggle = buttonHighlighted
+	}
+	tex := ui.stringToTexture(survival, sdl.Color{R: 139, G: 69, B: 19}, FontMedium)
+	_, _, w, h, _ := tex.Query()
+	rect := &sdl.Rect{X: ui.invOffsetX + ui.invWidth/2 - buttonStandard.W/4, Y: ui.invOffsetY + buttonStandard.H*7, W: buttonStandard.W / 2, H: buttonStandard.H / 2}
+	err := ui.renderer.Copy(ui.uipack, toggle, rect)
+	game.CheckError(err)
+	err = ui.renderer.Copy(tex, nil, &sdl.Rect{X: rect.X + rect.W/2 - w/2, Y: rect.Y + rect.H/2 - h/2, W: w, H: h})
+	game.CheckError(err)
+
 	for _, b := range ui.difficultyButtons {
 		var button *sdl.Rect
 		if b.highlighted {
@@ -265,7 +433,7 @@ func (ui *ui) displayDifficulty() {
 		} else {
 			button = buttonStandard
 		}
-		err := ui.renderer.Copy(ui.uipack, button, b.buttonRect)
+		err = ui.renderer.Copy(ui.uipack, button, b.buttonRect)
 		game.CheckError(err)
 		err = ui.renderer.Copy(b.buttonTexture, nil, b.buttonTextRect)
 		game.CheckError(err)
@@ -280,31 +448,38 @@ func (ui *ui) doDifficultyMenuAction() {
 
 	switch button.name {
 	case "Easy":
-		ui.inputChan <- &game.Input{Typ: game.SetDifficulty, Difficulty: 1}
+		ui.send(&game.Input{Typ: game.SetDifficulty, Difficulty: 1})
 	case "Medium":
-		ui.inputChan <- &game.Input{Typ: game.SetDifficulty, Difficulty: 2}
+		ui.send(&game.Input{Typ: game.SetDifficulty, Difficulty: 2})
 	case "Hard":
-		ui.inputChan <- &game.Input{Typ: game.SetDifficulty, Difficulty: 3}
+		ui.send(&game.Input{Typ: game.SetDifficulty, Difficulty: 3})
 	}
 }
 
 func (ui *ui) doStartMenuAction() {
 	button := ui.getStartMenuHighlightedButton()
 	switch button.name {
-	case "Start":
+	case "Start", "Continue":
 		ui.state = UIMain
-		var err error
-		ui.music, err = mix.LoadMUS("ui2d/assets/audio/music/cave themeb4.ogg")
-		game.CheckError(err)
-		err = ui.music.Play(-1)
+		ui.music = ui.loadMusic("assets/audio/music/cave themeb4.ogg")
+		err := ui.music.Play(-1)
 		game.CheckError(err)
 
-		ui.inputChan <- &game.Input{Typ: game.Restart, LevelChannel: ui.levelChan}
+		if button.name == "Continue" {
+			ui.send(&game.Input{Typ: game.LoadGame, LevelChannel: ui.levelChan})
+		} else {
+			ui.send(&game.Input{Typ: game.Restart, LevelChannel: ui.levelChan})
+		}
 	case "Difficulty":
 		ui.state = UIStartMenuDifficulty
 		ui.displayDifficulty()
+	case "Class":
+		if len(ui.classButtons) > 0 {
+			ui.state = UIStartMenuClass
+			ui.displayClasses()
+		}
 	case "Quit":
 		ui.state = UIMain
-		ui.inputChan <- &game.Input{Typ: game.CloseWindow, LevelChannel: ui.levelChan}
+		ui.send(&game.Input{Typ: game.CloseWindow, LevelChannel: ui.levelChan})
 	}
 }